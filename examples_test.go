@@ -51,7 +51,7 @@ func ExampleSpannerMigrator_MigrateUpSchema() {
 		log.Println("Error closing database:", closeErr)
 	}()
 
-	migrator, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, "test_db", container.opts...)
+	migrator, err := NewSpannerMigrator(ctx, container.ProjectID(), container.InstanceID(), "test_db", container.ClientOptions()...)
 	if err != nil {
 		panic(err)
 	}
@@ -81,7 +81,7 @@ func ExampleSpannerMigrator_MigrateUpData() {
 		log.Println("Error closing database:", closeErr)
 	}()
 
-	migrator, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, "test_db", container.opts...)
+	migrator, err := NewSpannerMigrator(ctx, container.ProjectID(), container.InstanceID(), "test_db", container.ClientOptions()...)
 	if err != nil {
 		panic(err)
 	}
@@ -111,3 +111,28 @@ func ExamplePostgresMigrator_MigrateUpSchema() {
 		panic(err)
 	}
 }
+
+func ExamplePostgresMigrator_MigrateUpData() {
+	ctx := context.Background()
+	container, err := NewPostgresContainer(ctx, "latest")
+	if err != nil {
+		panic(err)
+	}
+	defer container.Close()
+
+	db, err := container.CreateDatabase(ctx, "test_db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	migrator := NewPostgresMigrator(container.superUsername, "password", container.host, container.port.Port(), db.dbName, SSLModeDisable)
+
+	if err := migrator.MigrateUpSchema(ctx, "file://testdata/postgres/migrations"); err != nil {
+		panic(err)
+	}
+
+	if err := migrator.MigrateUpData(ctx, "file://testdata/postgres/datamigrations"); err != nil {
+		panic(err)
+	}
+}
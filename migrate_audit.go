@@ -0,0 +1,77 @@
+package dbinitiator
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"time"
+)
+
+// MigrationDirection is the direction a migration was run in
+type MigrationDirection string
+
+const (
+	// MigrationUp is an up migration
+	MigrationUp MigrationDirection = "up"
+
+	// MigrationDown is a down migration
+	MigrationDown MigrationDirection = "down"
+)
+
+// MigrationOutcome is the result of running a migration
+type MigrationOutcome string
+
+const (
+	// MigrationSucceeded is a migration that was applied completely
+	MigrationSucceeded MigrationOutcome = "succeeded"
+
+	// MigrationFailed is a migration that failed and left its track dirty
+	MigrationFailed MigrationOutcome = "failed"
+)
+
+// MigrationRecord is an entry in the audit log of a migrations track, written each time a migration is run
+type MigrationRecord struct {
+	Version uint
+
+	// Identifier is the name of the migration file without its version and suffix, e.g. "create_users"
+	Identifier string
+
+	Direction  MigrationDirection
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+
+	// Operator identifies who ran the migration, by default as <user>@<host>
+	Operator string
+
+	Outcome MigrationOutcome
+
+	// Error is the error message of a failed migration
+	Error string
+}
+
+// migrationAuditLog stores the audit log of a single track
+type migrationAuditLog interface {
+	// append adds rec to the log
+	append(ctx context.Context, rec *MigrationRecord) error
+
+	// records returns the log in the order the migrations were run
+	records(ctx context.Context) ([]MigrationRecord, error)
+
+	close()
+}
+
+// defaultOperator identifies the current process as <user>@<host>
+func defaultOperator() string {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return username + "@" + host
+}
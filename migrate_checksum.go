@@ -0,0 +1,156 @@
+package dbinitiator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// ChecksumPolicy decides what a migrator does when the file of an applied migration has changed since it was applied
+type ChecksumPolicy int
+
+const (
+	// ChecksumFail returns a *ChecksumMismatchError before any migration is run
+	ChecksumFail ChecksumPolicy = iota + 1
+
+	// ChecksumWarn logs the changed migrations and continues
+	ChecksumWarn
+)
+
+// ChecksumMismatch is an applied migration whose file no longer matches the checksum recorded when it was applied
+type ChecksumMismatch struct {
+	Version    uint
+	Identifier string
+
+	// Recorded is the checksum of the file when the migration was applied
+	Recorded string
+
+	// Current is the checksum of the file now. It is empty if the file has been removed from the source.
+	Current string
+}
+
+// String describes the mismatch
+func (m ChecksumMismatch) String() string {
+	if m.Current == "" {
+		return fmt.Sprintf("%d_%s: applied migration file is missing", m.Version, m.Identifier)
+	}
+
+	return fmt.Sprintf("%d_%s: applied migration file has changed (recorded %s, current %s)", m.Version, m.Identifier, m.Recorded, m.Current)
+}
+
+// ChecksumMismatchError is returned when applied migration files have changed and the policy is [ChecksumFail]
+type ChecksumMismatchError struct {
+	// MigrationsTable is the table of the track the migrations were applied to
+	MigrationsTable string
+
+	Mismatches []ChecksumMismatch
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %d applied migration(s) changed:", e.MigrationsTable, len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&b, "\n  %s", m)
+	}
+
+	return b.String()
+}
+
+// migrationHistory stores the checksum of every applied migration of a single track
+type migrationHistory interface {
+	// checksums returns the recorded checksums keyed by version
+	checksums(ctx context.Context) (map[uint]string, error)
+
+	// record stores the checksum of an applied migration, replacing any earlier record of version
+	record(ctx context.Context, version uint, identifier, checksum string) error
+
+	// removeAfter deletes the records of all versions greater than version
+	removeAfter(ctx context.Context, version int) error
+
+	close()
+}
+
+// migrationChecksum returns the hex encoded SHA-256 of a migration file
+func migrationChecksum(migr []byte) string {
+	sum := sha256.Sum256(migr)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// readUpMigration reads the up migration for version from src along with its identifier
+func readUpMigration(src source.Driver, version uint) ([]byte, string, error) {
+	r, identifier, err := src.ReadUp(version)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "source.Driver.ReadUp(): version %d", version)
+	}
+	defer r.Close()
+
+	migr, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "io.ReadAll(): version %d", version)
+	}
+
+	return migr, identifier, nil
+}
+
+// checksumMismatches compares the recorded checksums in history with the migration files in src
+func checksumMismatches(ctx context.Context, history migrationHistory, src source.Driver) ([]ChecksumMismatch, error) {
+	recorded, err := history.checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]uint, 0, len(recorded))
+	for v := range recorded {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+
+	var mismatches []ChecksumMismatch
+	for _, version := range versions {
+		migr, identifier, err := readUpMigration(src, version)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			mismatches = append(mismatches, ChecksumMismatch{Version: version, Recorded: recorded[version]})
+		case err != nil:
+			return nil, err
+		default:
+			if current := migrationChecksum(migr); current != recorded[version] {
+				mismatches = append(mismatches, ChecksumMismatch{Version: version, Identifier: identifier, Recorded: recorded[version], Current: current})
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// verifyChecksums applies policy to the applied migrations of migrationsTable whose files in src have changed
+func verifyChecksums(ctx context.Context, history migrationHistory, src source.Driver, migrationsTable string, policy ChecksumPolicy) error {
+	mismatches, err := checksumMismatches(ctx, history, src)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	if policy == ChecksumWarn {
+		for _, m := range mismatches {
+			ccclogger.FromCtx(ctx).Warnf("%s: %s", migrationsTable, m)
+		}
+
+		return nil
+	}
+
+	return &ChecksumMismatchError{MigrationsTable: migrationsTable, Mismatches: mismatches}
+}
@@ -0,0 +1,83 @@
+package dbinitiator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func Test_verifyChecksums(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"000001_create.up.sql": {Data: []byte("CREATE 1")},
+		"000002_alter.up.sql":  {Data: []byte("ALTER 2 edited")},
+	}
+
+	tests := []struct {
+		name           string
+		history        memoryHistory
+		policy         ChecksumPolicy
+		wantMismatches []ChecksumMismatch
+	}{
+		{
+			name: "unchanged files",
+			history: memoryHistory{
+				1: migrationChecksum([]byte("CREATE 1")),
+			},
+			policy: ChecksumFail,
+		},
+		{
+			name: "changed and missing files fail",
+			history: memoryHistory{
+				1: migrationChecksum([]byte("CREATE 1")),
+				2: migrationChecksum([]byte("ALTER 2")),
+				3: migrationChecksum([]byte("INDEX 3")),
+			},
+			policy: ChecksumFail,
+			wantMismatches: []ChecksumMismatch{
+				{Version: 2, Identifier: "alter", Recorded: migrationChecksum([]byte("ALTER 2")), Current: migrationChecksum([]byte("ALTER 2 edited"))},
+				{Version: 3, Recorded: migrationChecksum([]byte("INDEX 3"))},
+			},
+		},
+		{
+			name: "changed files only warn",
+			history: memoryHistory{
+				2: migrationChecksum([]byte("ALTER 2")),
+			},
+			policy: ChecksumWarn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := iofs.New(fsys, ".")
+			if err != nil {
+				t.Fatalf("iofs.New() error = %v", err)
+			}
+			defer src.Close()
+
+			err = verifyChecksums(context.Background(), tt.history, src, "SchemaMigrations", tt.policy)
+			if tt.wantMismatches == nil {
+				if err != nil {
+					t.Errorf("verifyChecksums() error = %v", err)
+				}
+
+				return
+			}
+
+			var mismatchErr *ChecksumMismatchError
+			if !errors.As(err, &mismatchErr) {
+				t.Fatalf("verifyChecksums() error = %v, want *ChecksumMismatchError", err)
+			}
+			if !reflect.DeepEqual(mismatchErr.Mismatches, tt.wantMismatches) {
+				t.Errorf("ChecksumMismatchError.Mismatches = %v, want %v", mismatchErr.Mismatches, tt.wantMismatches)
+			}
+		})
+	}
+}
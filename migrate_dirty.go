@@ -0,0 +1,128 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// DirtyError is returned when a migrations track is dirty because a migration failed part way through.
+//
+// Repair the database by hand, then either force the version or retry the failed migration.
+type DirtyError struct {
+	// MigrationsTable is the table of the dirty track
+	MigrationsTable string
+
+	// Version is the version of the migration that failed
+	Version uint
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("%s is dirty at version %d: repair the database, then force the version or retry the migration", e.MigrationsTable, e.Version)
+}
+
+// dirtyError converts migrate.ErrDirty into a *DirtyError. If err is from a migration that failed
+// and left the track dirty, the returned error wraps both err and a *DirtyError for the failed version.
+func dirtyError(m *migrate.Migrate, migrationsTable string, err error) error {
+	var dirtyErr migrate.ErrDirty
+	if errors.As(err, &dirtyErr) {
+		return &DirtyError{MigrationsTable: migrationsTable, Version: uint(dirtyErr.Version)}
+	}
+
+	if version, dirty, vErr := m.Version(); vErr == nil && dirty {
+		return errors.Join(err, &DirtyError{MigrationsTable: migrationsTable, Version: version})
+	}
+
+	return err
+}
+
+// resetVersion clears the version of a clean track so all migrations in the next source are applied
+func resetVersion(m *migrate.Migrate, migrationsTable string) error {
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "migrate.Migrate.Version()")
+	case dirty:
+		return &DirtyError{MigrationsTable: migrationsTable, Version: version}
+	}
+
+	if err := m.Force(-1); err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Force()")
+	}
+
+	return nil
+}
+
+// checkSourceAhead returns an error when the last version in the source at sourceURL is at or before the current
+// version of m, as Up would apply nothing from it. This is the case for a source numbered from 1 that follows another
+// source without a version reset. A dirty version is left for Up to report.
+func checkSourceAhead(m *migrate.Migrate, fsys fs.FS, sourceURL string) error {
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion), err == nil && dirty:
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "migrate.Migrate.Version()")
+	}
+
+	src, err := openSource(fsys, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	last, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	for err == nil {
+		var next uint
+		if next, err = src.Next(last); err == nil {
+			last = next
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "source.Driver.Next()")
+	}
+
+	if last <= version {
+		return errors.Newf("%s ends at version %d, which is not after the current version %d: use WithVersionReset to apply sources that are each numbered from 1", sourceURL, last, version)
+	}
+
+	return nil
+}
+
+// retryDirty re-runs the up migration that left the track dirty.
+// The version is forced back to the previous migration in src before the failed migration is applied again.
+func retryDirty(m *migrate.Migrate, src source.Driver) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Version()")
+	}
+	if !dirty {
+		return errors.Newf("version %d is not dirty", version)
+	}
+
+	prev := -1
+	if v, err := src.Prev(version); err == nil {
+		prev = int(v)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(err, "source.Driver.Prev(): version %d", version)
+	}
+
+	if err := m.Force(prev); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Force(): version %d", prev)
+	}
+
+	if err := m.Migrate(version); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Migrate(): version %d", version)
+	}
+
+	return nil
+}
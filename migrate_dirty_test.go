@@ -0,0 +1,30 @@
+package dbinitiator
+
+import (
+	"testing"
+
+	"github.com/go-playground/errors/v5"
+)
+
+func TestDirtyError(t *testing.T) {
+	t.Parallel()
+
+	migrationErr := errors.New("migration failed")
+	err := errors.Wrap(errors.Join(migrationErr, &DirtyError{MigrationsTable: "SchemaMigrations", Version: 3}), "migrate.Migrate.Up()")
+
+	var dirtyErr *DirtyError
+	if !errors.As(err, &dirtyErr) {
+		t.Fatalf("errors.As() = false, want *DirtyError in %v", err)
+	}
+	if dirtyErr.Version != 3 {
+		t.Errorf("DirtyError.Version = %d, want 3", dirtyErr.Version)
+	}
+	if !errors.Is(err, migrationErr) {
+		t.Errorf("errors.Is() = false, want the migration error in %v", err)
+	}
+
+	want := "SchemaMigrations is dirty at version 3: repair the database, then force the version or retry the migration"
+	if got := dirtyErr.Error(); got != want {
+		t.Errorf("DirtyError.Error() = %q, want %q", got, want)
+	}
+}
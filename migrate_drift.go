@@ -0,0 +1,76 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaDrift is the difference between the schema a migrations source produces and the schema of a live database.
+// Statements are normalized, so differences in whitespace or statement order are not drift.
+type SchemaDrift struct {
+	// Missing are statements the migrations produce that the live database does not have
+	Missing []string
+
+	// Unexpected are statements the live database has that the migrations do not produce, e.g. changes made by hand
+	Unexpected []string
+}
+
+// HasDrift reports whether the live database differs from the migrations
+func (d *SchemaDrift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Unexpected) > 0
+}
+
+// String renders the drift as a diff from the migrations to the live database
+func (d *SchemaDrift) String() string {
+	if !d.HasDrift() {
+		return "no schema drift\n"
+	}
+
+	var b strings.Builder
+	for _, stmt := range d.Missing {
+		fmt.Fprintf(&b, "- %s\n", stmt)
+	}
+	for _, stmt := range d.Unexpected {
+		fmt.Fprintf(&b, "+ %s\n", stmt)
+	}
+
+	return b.String()
+}
+
+// newSchemaDrift compares the statements the migrations produce with the statements of the live database
+func newSchemaDrift(expected, live []string) *SchemaDrift {
+	want := normalizeStatements(expected)
+	got := normalizeStatements(live)
+
+	drift := &SchemaDrift{}
+	for _, stmt := range want {
+		if !slices.Contains(got, stmt) {
+			drift.Missing = append(drift.Missing, stmt)
+		}
+	}
+	for _, stmt := range got {
+		if !slices.Contains(want, stmt) {
+			drift.Unexpected = append(drift.Unexpected, stmt)
+		}
+	}
+
+	return drift
+}
+
+// normalizeStatements collapses whitespace, drops trailing semicolons and sorts the statements
+func normalizeStatements(stmts []string) []string {
+	normalized := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		stmt = strings.Join(strings.Fields(stmt), " ")
+		stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+		stmt = strings.ReplaceAll(stmt, "( ", "(")
+		stmt = strings.ReplaceAll(stmt, " )", ")")
+		if stmt != "" {
+			normalized = append(normalized, stmt)
+		}
+	}
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,62 @@
+package dbinitiator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_newSchemaDrift(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		expected       []string
+		live           []string
+		wantMissing    []string
+		wantUnexpected []string
+		wantString     string
+	}{
+		{
+			name: "whitespace and order are not drift",
+			expected: []string{
+				"CREATE TABLE Accounts (\n  Id STRING(36) NOT NULL,\n) PRIMARY KEY(Id)",
+				"CREATE INDEX Accounts_Email ON Accounts(Email);",
+			},
+			live: []string{
+				"CREATE INDEX Accounts_Email ON Accounts(Email)",
+				"CREATE TABLE Accounts ( Id STRING(36) NOT NULL, ) PRIMARY KEY(Id)",
+			},
+			wantString: "no schema drift\n",
+		},
+		{
+			name: "changes made by hand",
+			expected: []string{
+				"CREATE TABLE Accounts (Id STRING(36) NOT NULL) PRIMARY KEY(Id)",
+				"CREATE INDEX Accounts_Email ON Accounts(Email)",
+			},
+			live: []string{
+				"CREATE TABLE Accounts (Id STRING(36) NOT NULL) PRIMARY KEY(Id)",
+				"CREATE INDEX Accounts_Name ON Accounts(Name)",
+			},
+			wantMissing:    []string{"CREATE INDEX Accounts_Email ON Accounts(Email)"},
+			wantUnexpected: []string{"CREATE INDEX Accounts_Name ON Accounts(Name)"},
+			wantString:     "- CREATE INDEX Accounts_Email ON Accounts(Email)\n+ CREATE INDEX Accounts_Name ON Accounts(Name)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			drift := newSchemaDrift(tt.expected, tt.live)
+			if !reflect.DeepEqual(drift.Missing, tt.wantMissing) {
+				t.Errorf("SchemaDrift.Missing = %q, want %q", drift.Missing, tt.wantMissing)
+			}
+			if !reflect.DeepEqual(drift.Unexpected, tt.wantUnexpected) {
+				t.Errorf("SchemaDrift.Unexpected = %q, want %q", drift.Unexpected, tt.wantUnexpected)
+			}
+			if got := drift.String(); got != tt.wantString {
+				t.Errorf("SchemaDrift.String() = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
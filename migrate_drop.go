@@ -0,0 +1,125 @@
+package dbinitiator
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/errors/v5"
+)
+
+// DropFilter limits MigrateDropSchema to some of the tables and views.
+//
+// Patterns use path.Match syntax, e.g. "Audit*", and match the name with or without its schema. Objects that belong
+// to a dropped table or view are dropped with it: its indexes, the foreign keys on it or referencing it and the
+// privileges granted on it. Tables and views that depend on a dropped object, such as interleaved or inherited child
+// tables and views reading from it, are dropped too. Objects that belong to no table or view, such as sequences,
+// functions and roles, are kept.
+type DropFilter struct {
+	// Include drops only the tables and views matching one of the patterns. Everything is dropped when empty.
+	Include []string
+
+	// Exclude keeps the tables and views matching one of the patterns, e.g. audit or migration history tables.
+	// It is an error for a kept object to depend on a dropped one.
+	Exclude []string
+}
+
+func (f DropFilter) isZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+func (f DropFilter) validate() error {
+	for _, pattern := range slices.Concat(f.Include, f.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "path.Match(): drop filter pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// selects reports whether the filter drops the object name on its own account
+func (f DropFilter) selects(name string) bool {
+	return (len(f.Include) == 0 || matchesAny(f.Include, name)) && !f.excludes(name)
+}
+
+func (f DropFilter) excludes(name string) bool {
+	return matchesAny(f.Exclude, name)
+}
+
+// matchesAny reports whether name, with or without its schema, matches one of the patterns.
+// The patterns must have been validated.
+func matchesAny(patterns []string, name string) bool {
+	_, unqualified, _ := strings.Cut(name, ".")
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, unqualified); ok && unqualified != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropStatement is a statement that drops an object, along with the tables and views the object belongs to
+type dropStatement struct {
+	SQL string
+
+	// Objects are the tables and views the statement depends on. The statement is part of a filtered drop when
+	// one of them is dropped. It is empty for objects that belong to no table or view.
+	Objects []string
+}
+
+// dropObject is a table or view, along with the tables and views it cannot outlive
+type dropObject struct {
+	Name     string
+	Requires []string
+}
+
+// droppedObjects returns the names of the objects the filter drops, including those that depend on a dropped object
+func droppedObjects(filter DropFilter, objects []dropObject) (map[string]bool, error) {
+	dropped := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		if filter.selects(o.Name) {
+			dropped[o.Name] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, o := range objects {
+			if dropped[o.Name] {
+				continue
+			}
+			for _, req := range o.Requires {
+				if !dropped[req] {
+					continue
+				}
+				if filter.excludes(o.Name) {
+					return nil, errors.Newf("%s is excluded from the drop but depends on %s, which is dropped", o.Name, req)
+				}
+				dropped[o.Name] = true
+				changed = true
+
+				break
+			}
+		}
+	}
+
+	return dropped, nil
+}
+
+// filterDropStatements returns the SQL of the statements that belong to a dropped object, or of every statement
+// when dropped is nil
+func filterDropStatements(stmts []dropStatement, dropped map[string]bool) []string {
+	sqls := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		if dropped == nil || slices.ContainsFunc(stmt.Objects, func(o string) bool { return dropped[o] }) {
+			sqls = append(sqls, stmt.SQL)
+		}
+	}
+
+	return sqls
+}
@@ -0,0 +1,137 @@
+package dbinitiator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_droppedObjects(t *testing.T) {
+	t.Parallel()
+
+	objects := []dropObject{
+		{Name: "Accounts"},
+		{Name: "AccountEvents", Requires: []string{"Accounts"}},
+		{Name: "AuditLog"},
+		{Name: "tmp_Imports"},
+		{Name: "tmp_Rows", Requires: []string{"tmp_Imports"}},
+		{Name: "ImportSummary", Requires: []string{"tmp_Rows"}},
+		{Name: "Sales.Invoices"},
+	}
+
+	tests := []struct {
+		name    string
+		filter  DropFilter
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "exclude keeps matching objects",
+			filter: DropFilter{Exclude: []string{"Audit*"}},
+			want:   []string{"AccountEvents", "Accounts", "ImportSummary", "Sales.Invoices", "tmp_Imports", "tmp_Rows"},
+		},
+		{
+			name:   "include cascades to dependent objects at any depth",
+			filter: DropFilter{Include: []string{"tmp_*"}},
+			want:   []string{"ImportSummary", "tmp_Imports", "tmp_Rows"},
+		},
+		{
+			name:   "patterns match names with or without their schema",
+			filter: DropFilter{Include: []string{"Invoices", "Account?"}},
+			want:   []string{"AccountEvents", "Accounts", "Sales.Invoices"},
+		},
+		{
+			name:   "include and exclude combine",
+			filter: DropFilter{Include: []string{"Sales.*", "AuditLog"}, Exclude: []string{"Audit*"}},
+			want:   []string{"Sales.Invoices"},
+		},
+		{
+			name:    "excluded object depending on a dropped object",
+			filter:  DropFilter{Include: []string{"Accounts"}, Exclude: []string{"AccountEvents"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dropped, err := droppedObjects(tt.filter, objects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("droppedObjects() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got []string
+			for _, o := range objects {
+				if dropped[o.Name] {
+					got = append(got, o.Name)
+				}
+			}
+			want := make(map[string]bool)
+			for _, name := range tt.want {
+				want[name] = true
+			}
+			if !reflect.DeepEqual(dropped, want) {
+				t.Errorf("droppedObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterDropStatements(t *testing.T) {
+	t.Parallel()
+
+	stmts := []dropStatement{
+		{SQL: "DROP VIEW ImportSummary", Objects: []string{"ImportSummary"}},
+		{SQL: "ALTER TABLE Accounts DROP CONSTRAINT FK_Accounts_Imports", Objects: []string{"Accounts", "Imports"}},
+		{SQL: "DROP INDEX Accounts_Email", Objects: []string{"Accounts"}},
+		{SQL: "DROP TABLE Imports", Objects: []string{"Imports"}},
+		{SQL: "DROP SEQUENCE ImportIds"},
+	}
+
+	tests := []struct {
+		name    string
+		dropped map[string]bool
+		want    []string
+	}{
+		{
+			name: "every statement without a filter",
+			want: []string{
+				"DROP VIEW ImportSummary",
+				"ALTER TABLE Accounts DROP CONSTRAINT FK_Accounts_Imports",
+				"DROP INDEX Accounts_Email",
+				"DROP TABLE Imports",
+				"DROP SEQUENCE ImportIds",
+			},
+		},
+		{
+			name:    "statements of dropped objects, including foreign keys referencing them",
+			dropped: map[string]bool{"Imports": true},
+			want: []string{
+				"ALTER TABLE Accounts DROP CONSTRAINT FK_Accounts_Imports",
+				"DROP TABLE Imports",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := filterDropStatements(stmts, tt.dropped); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterDropStatements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropFilter_validate(t *testing.T) {
+	t.Parallel()
+
+	if err := (DropFilter{Include: []string{"tmp_*"}, Exclude: []string{"Audit?"}}).validate(); err != nil {
+		t.Errorf("DropFilter.validate() error = %v", err)
+	}
+	if err := (DropFilter{Exclude: []string{"Audit["}}).validate(); err == nil {
+		t.Errorf("DropFilter.validate() error = nil, want error for a malformed pattern")
+	}
+}
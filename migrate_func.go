@@ -0,0 +1,145 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// goMigrationMarker starts the body the source reports for a Go migration function,
+// which lets the database driver tell it apart from a migration file
+const goMigrationMarker = "-- dbinitiator:go-migration "
+
+// goMigrationSource merges registered Go migration functions into the versions of a source.
+// Go migrations are up only, so they have no down migration.
+type goMigrationSource struct {
+	source.Driver
+	identifiers map[uint]string
+	versions    []uint
+}
+
+// newGoMigrationSource wraps src with the Go migrations in identifiers, keyed by version.
+// It takes ownership of src and closes it on error.
+func newGoMigrationSource(src source.Driver, identifiers map[uint]string) (source.Driver, error) {
+	versions := make([]uint, 0, len(identifiers))
+	for v := range identifiers {
+		versions = append(versions, v)
+	}
+
+	v, err := src.First()
+	for ; err == nil; v, err = src.Next(v) {
+		if identifier, ok := identifiers[v]; ok {
+			_ = src.Close()
+
+			return nil, errors.Newf("version %d has both a migration file and the Go migration function %q", v, identifier)
+		}
+		versions = append(versions, v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		_ = src.Close()
+
+		return nil, errors.Wrap(err, "source.Driver.Next()")
+	}
+
+	slices.Sort(versions)
+
+	return &goMigrationSource{Driver: src, identifiers: identifiers, versions: versions}, nil
+}
+
+func (s *goMigrationSource) Open(_ string) (source.Driver, error) {
+	return nil, errors.New("goMigrationSource.Open(): not supported")
+}
+
+func (s *goMigrationSource) First() (uint, error) {
+	if len(s.versions) == 0 {
+		return 0, &fs.PathError{Op: "first", Err: fs.ErrNotExist}
+	}
+
+	return s.versions[0], nil
+}
+
+func (s *goMigrationSource) Prev(version uint) (uint, error) {
+	i, ok := slices.BinarySearch(s.versions, version)
+	if !ok || i == 0 {
+		return 0, &fs.PathError{Op: "prev for version " + strconv.FormatUint(uint64(version), 10), Err: fs.ErrNotExist}
+	}
+
+	return s.versions[i-1], nil
+}
+
+func (s *goMigrationSource) Next(version uint) (uint, error) {
+	i, ok := slices.BinarySearch(s.versions, version)
+	if !ok || i == len(s.versions)-1 {
+		return 0, &fs.PathError{Op: "next for version " + strconv.FormatUint(uint64(version), 10), Err: fs.ErrNotExist}
+	}
+
+	return s.versions[i+1], nil
+}
+
+func (s *goMigrationSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	if identifier, ok := s.identifiers[version]; ok {
+		return io.NopCloser(strings.NewReader(goMigrationMarker + strconv.FormatUint(uint64(version), 10))), identifier, nil
+	}
+
+	r, identifier, err := s.Driver.ReadUp(version)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "source.Driver.ReadUp(): version %d", version)
+	}
+
+	return r, identifier, nil
+}
+
+func (s *goMigrationSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	if _, ok := s.identifiers[version]; ok {
+		return nil, "", &fs.PathError{Op: "read down for version " + strconv.FormatUint(uint64(version), 10), Err: fs.ErrNotExist}
+	}
+
+	r, identifier, err := s.Driver.ReadDown(version)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "source.Driver.ReadDown(): version %d", version)
+	}
+
+	return r, identifier, nil
+}
+
+// goMigrationDriver runs Go migration functions in place of the marker body reported by goMigrationSource.
+// All other migrations are passed through to the wrapped driver.
+type goMigrationDriver struct {
+	database.Driver
+	ctx context.Context
+	run func(ctx context.Context, version uint) error
+}
+
+func (d *goMigrationDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return errors.Wrap(err, "io.ReadAll()")
+	}
+
+	if v, ok := bytes.CutPrefix(body, []byte(goMigrationMarker)); ok {
+		version, err := strconv.ParseUint(string(v), 10, 0)
+		if err != nil {
+			return errors.Wrapf(err, "strconv.ParseUint(): invalid Go migration version %q", v)
+		}
+
+		if err := d.run(d.ctx, uint(version)); err != nil {
+			return &database.Error{OrigErr: err, Err: "go migration failed", Query: body}
+		}
+
+		return nil
+	}
+
+	if err := d.Driver.Run(bytes.NewReader(body)); err != nil {
+		return errors.Wrap(err, "database.Driver.Run()")
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func Test_goMigrationSource(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"000001_create.up.sql":   {Data: []byte("CREATE 1")},
+		"000001_create.down.sql": {Data: []byte("DROP 1")},
+		"000003_insert.up.sql":   {Data: []byte("INSERT 3")},
+		"000003_insert.down.sql": {Data: []byte("DELETE 3")},
+	}
+
+	tests := []struct {
+		name        string
+		identifiers map[uint]string
+		failVersion uint
+		wantRun     []string
+		wantVersion uint
+		wantErr     bool
+		wantDirty   bool
+	}{
+		{
+			name:        "Go migrations interleaved with files",
+			identifiers: map[uint]string{2: "backfill", 4: "reencode"},
+			wantRun:     []string{"CREATE 1", "go 2", "INSERT 3", "go 4"},
+			wantVersion: 4,
+		},
+		{
+			name:        "failed Go migration leaves version dirty",
+			identifiers: map[uint]string{2: "backfill", 4: "reencode"},
+			failVersion: 2,
+			wantRun:     []string{"CREATE 1", "go 2"},
+			wantVersion: 2,
+			wantErr:     true,
+			wantDirty:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileSrc, err := iofs.New(fsys, ".")
+			if err != nil {
+				t.Fatalf("iofs.New() error = %v", err)
+			}
+			src, err := newGoMigrationSource(fileSrc, tt.identifiers)
+			if err != nil {
+				t.Fatalf("newGoMigrationSource() error = %v", err)
+			}
+
+			db, err := stub.WithInstance(nil, &stub.Config{})
+			if err != nil {
+				t.Fatalf("stub.WithInstance() error = %v", err)
+			}
+			stubDB, ok := db.(*stub.Stub)
+			if !ok {
+				t.Fatalf("stub.WithInstance() = %T, want *stub.Stub", db)
+			}
+
+			var gotRun []string
+			driver := &goMigrationDriver{
+				Driver: db,
+				ctx:    context.Background(),
+				run: func(_ context.Context, version uint) error {
+					gotRun = append(gotRun, stubDB.MigrationSequence...)
+					stubDB.MigrationSequence = stubDB.MigrationSequence[:0]
+					gotRun = append(gotRun, fmt.Sprintf("go %d", version))
+					if version == tt.failVersion {
+						return errors.New("backfill failed")
+					}
+
+					return nil
+				},
+			}
+
+			m, err := migrate.NewWithInstance("iofs", src, "stub", driver)
+			if err != nil {
+				t.Fatalf("migrate.NewWithInstance() error = %v", err)
+			}
+			defer m.Close()
+
+			if err := m.Up(); (err != nil) != tt.wantErr {
+				t.Fatalf("migrate.Migrate.Up() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			gotRun = append(gotRun, stubDB.MigrationSequence...)
+
+			if !reflect.DeepEqual(gotRun, tt.wantRun) {
+				t.Errorf("migrations run = %v, want %v", gotRun, tt.wantRun)
+			}
+			version, dirty, err := m.Version()
+			if err != nil {
+				t.Fatalf("migrate.Migrate.Version() error = %v", err)
+			}
+			if version != tt.wantVersion || dirty != tt.wantDirty {
+				t.Errorf("migrate.Migrate.Version() = %d, dirty=%v, want %d, dirty=%v", version, dirty, tt.wantVersion, tt.wantDirty)
+			}
+		})
+	}
+}
+
+func Test_newGoMigrationSource_conflict(t *testing.T) {
+	t.Parallel()
+
+	fileSrc, err := iofs.New(fstest.MapFS{"000001_create.up.sql": {Data: []byte("CREATE 1")}}, ".")
+	if err != nil {
+		t.Fatalf("iofs.New() error = %v", err)
+	}
+
+	if _, err := newGoMigrationSource(fileSrc, map[uint]string{1: "backfill"}); err == nil {
+		t.Errorf("newGoMigrationSource() error = nil, want error for version used by a file and a Go migration")
+	}
+}
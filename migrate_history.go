@@ -0,0 +1,147 @@
+package dbinitiator
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// historyDriver records the checksum and an audit log entry of each migration the wrapped driver runs.
+//
+// migrate marks the target version dirty before running a migration and clean once it succeeds,
+// so a clean SetVersion that follows a dirty one completes a migration. Any other clean SetVersion,
+// such as from Force, discards the checksums above the new version.
+type historyDriver struct {
+	database.Driver
+	ctx context.Context
+	src source.Driver
+
+	// history is nil when checksums are not recorded
+	history migrationHistory
+
+	// audit is nil when no audit log is kept
+	audit    migrationAuditLog
+	operator string
+
+	running bool
+	current MigrationRecord
+}
+
+func (d *historyDriver) SetVersion(version int, dirty bool) error {
+	if dirty {
+		if err := d.start(version); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Driver.SetVersion(version, dirty); err != nil {
+		return errors.Wrap(err, "database.Driver.SetVersion()")
+	}
+	if dirty {
+		return nil
+	}
+
+	running := d.running
+	d.running = false
+
+	if d.history != nil {
+		if running && d.current.Direction == MigrationUp {
+			migr, _, err := readUpMigration(d.src, d.current.Version)
+			if err != nil {
+				return err
+			}
+			if err := d.history.record(d.ctx, d.current.Version, d.current.Identifier, migrationChecksum(migr)); err != nil {
+				return errors.Wrapf(err, "migrationHistory.record(): version %d", d.current.Version)
+			}
+		} else if err := d.history.removeAfter(d.ctx, version); err != nil {
+			return errors.Wrapf(err, "migrationHistory.removeAfter(): version %d", version)
+		}
+	}
+
+	if running {
+		return d.finish(MigrationSucceeded, nil)
+	}
+
+	return nil
+}
+
+func (d *historyDriver) Run(migration io.Reader) error {
+	if err := d.Driver.Run(migration); err != nil {
+		if d.running {
+			d.running = false
+			if auditErr := d.finish(MigrationFailed, err); auditErr != nil {
+				return errors.Join(errors.Wrap(err, "database.Driver.Run()"), auditErr)
+			}
+		}
+
+		return errors.Wrap(err, "database.Driver.Run()")
+	}
+
+	return nil
+}
+
+func (d *historyDriver) Close() error {
+	if d.history != nil {
+		defer d.history.close()
+	}
+	if d.audit != nil {
+		defer d.audit.close()
+	}
+
+	if err := d.Driver.Close(); err != nil {
+		return errors.Wrap(err, "database.Driver.Close()")
+	}
+
+	return nil
+}
+
+// start begins tracking the migration to version, which is about to be marked dirty
+func (d *historyDriver) start(version int) error {
+	current, _, err := d.Driver.Version()
+	if err != nil {
+		return errors.Wrap(err, "database.Driver.Version()")
+	}
+
+	d.running = true
+	d.current = MigrationRecord{Direction: MigrationUp, Version: uint(version), StartedAt: time.Now(), Operator: d.operator}
+	if current != database.NilVersion && version < current {
+		d.current.Direction = MigrationDown
+		d.current.Version = uint(current)
+	}
+
+	if d.current.Direction == MigrationUp {
+		if _, identifier, err := readUpMigration(d.src, d.current.Version); err == nil {
+			d.current.Identifier = identifier
+		}
+	} else if r, identifier, err := d.src.ReadDown(d.current.Version); err == nil {
+		_ = r.Close()
+		d.current.Identifier = identifier
+	}
+
+	return nil
+}
+
+// finish appends the tracked migration to the audit log
+func (d *historyDriver) finish(outcome MigrationOutcome, runErr error) error {
+	if d.audit == nil {
+		return nil
+	}
+
+	rec := d.current
+	rec.FinishedAt = time.Now()
+	rec.Duration = rec.FinishedAt.Sub(rec.StartedAt)
+	rec.Outcome = outcome
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+
+	if err := d.audit.append(d.ctx, &rec); err != nil {
+		return errors.Wrapf(err, "migrationAuditLog.append(): version %d", rec.Version)
+	}
+
+	return nil
+}
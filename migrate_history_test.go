@@ -0,0 +1,223 @@
+package dbinitiator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// memoryHistory is a migrationHistory kept in memory
+type memoryHistory map[uint]string
+
+func (h memoryHistory) checksums(_ context.Context) (map[uint]string, error) {
+	return h, nil
+}
+
+func (h memoryHistory) record(_ context.Context, version uint, _, checksum string) error {
+	h[version] = checksum
+
+	return nil
+}
+
+func (h memoryHistory) removeAfter(_ context.Context, version int) error {
+	for v := range h {
+		if int(v) > version {
+			delete(h, v)
+		}
+	}
+
+	return nil
+}
+
+func (h memoryHistory) close() {}
+
+// memoryAuditLog is a migrationAuditLog kept in memory
+type memoryAuditLog struct {
+	log []MigrationRecord
+}
+
+func (a *memoryAuditLog) append(_ context.Context, rec *MigrationRecord) error {
+	a.log = append(a.log, *rec)
+
+	return nil
+}
+
+func (a *memoryAuditLog) records(_ context.Context) ([]MigrationRecord, error) {
+	return a.log, nil
+}
+
+func (a *memoryAuditLog) close() {}
+
+// failingDriver fails to run the migration with the body fail
+type failingDriver struct {
+	database.Driver
+	fail string
+}
+
+func (d *failingDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	if string(body) == d.fail {
+		return errors.Newf("failed to run %s", body)
+	}
+
+	return d.Driver.Run(bytes.NewReader(body))
+}
+
+func Test_historyDriver(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"000001_create.up.sql":   {Data: []byte("CREATE 1")},
+		"000001_create.down.sql": {Data: []byte("DROP 1")},
+		"000002_alter.up.sql":    {Data: []byte("ALTER 2")},
+		"000002_alter.down.sql":  {Data: []byte("UNALTER 2")},
+		"000003_index.up.sql":    {Data: []byte("INDEX 3")},
+		"000003_index.down.sql":  {Data: []byte("UNINDEX 3")},
+	}
+
+	type audited struct {
+		Version    uint
+		Identifier string
+		Direction  MigrationDirection
+		Outcome    MigrationOutcome
+	}
+
+	tests := []struct {
+		name        string
+		fail        string
+		migrate     func(m *migrate.Migrate) error
+		wantErr     bool
+		wantHistory memoryHistory
+		wantAudit   []audited
+	}{
+		{
+			name:    "up records every migration",
+			migrate: func(m *migrate.Migrate) error { return m.Up() },
+			wantHistory: memoryHistory{
+				1: migrationChecksum([]byte("CREATE 1")),
+				2: migrationChecksum([]byte("ALTER 2")),
+				3: migrationChecksum([]byte("INDEX 3")),
+			},
+			wantAudit: []audited{
+				{Version: 1, Identifier: "create", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 2, Identifier: "alter", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 3, Identifier: "index", Direction: MigrationUp, Outcome: MigrationSucceeded},
+			},
+		},
+		{
+			name: "down removes reverted migrations",
+			migrate: func(m *migrate.Migrate) error {
+				if err := m.Up(); err != nil {
+					return err
+				}
+
+				return m.Migrate(1)
+			},
+			wantHistory: memoryHistory{1: migrationChecksum([]byte("CREATE 1"))},
+			wantAudit: []audited{
+				{Version: 1, Identifier: "create", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 2, Identifier: "alter", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 3, Identifier: "index", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 3, Identifier: "index", Direction: MigrationDown, Outcome: MigrationSucceeded},
+				{Version: 2, Identifier: "alter", Direction: MigrationDown, Outcome: MigrationSucceeded},
+			},
+		},
+		{
+			name: "force removes migrations above the version",
+			migrate: func(m *migrate.Migrate) error {
+				if err := m.Up(); err != nil {
+					return err
+				}
+
+				return m.Force(2)
+			},
+			wantHistory: memoryHistory{
+				1: migrationChecksum([]byte("CREATE 1")),
+				2: migrationChecksum([]byte("ALTER 2")),
+			},
+			wantAudit: []audited{
+				{Version: 1, Identifier: "create", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 2, Identifier: "alter", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 3, Identifier: "index", Direction: MigrationUp, Outcome: MigrationSucceeded},
+			},
+		},
+		{
+			name:        "failed migration is audited but not recorded",
+			fail:        "ALTER 2",
+			migrate:     func(m *migrate.Migrate) error { return m.Up() },
+			wantErr:     true,
+			wantHistory: memoryHistory{1: migrationChecksum([]byte("CREATE 1"))},
+			wantAudit: []audited{
+				{Version: 1, Identifier: "create", Direction: MigrationUp, Outcome: MigrationSucceeded},
+				{Version: 2, Identifier: "alter", Direction: MigrationUp, Outcome: MigrationFailed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := iofs.New(fsys, ".")
+			if err != nil {
+				t.Fatalf("iofs.New() error = %v", err)
+			}
+			db, err := stub.WithInstance(nil, &stub.Config{})
+			if err != nil {
+				t.Fatalf("stub.WithInstance() error = %v", err)
+			}
+
+			history := memoryHistory{}
+			audit := &memoryAuditLog{}
+			driver := &historyDriver{
+				Driver:   &failingDriver{Driver: db, fail: tt.fail},
+				ctx:      context.Background(),
+				src:      src,
+				history:  history,
+				audit:    audit,
+				operator: "tester@host",
+			}
+
+			m, err := migrate.NewWithInstance("iofs", src, "stub", driver)
+			if err != nil {
+				t.Fatalf("migrate.NewWithInstance() error = %v", err)
+			}
+			defer m.Close()
+
+			if err := tt.migrate(m); (err != nil) != tt.wantErr {
+				t.Fatalf("migrate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(history, tt.wantHistory) {
+				t.Errorf("history = %v, want %v", history, tt.wantHistory)
+			}
+
+			var gotAudit []audited
+			for _, rec := range audit.log {
+				gotAudit = append(gotAudit, audited{Version: rec.Version, Identifier: rec.Identifier, Direction: rec.Direction, Outcome: rec.Outcome})
+				if rec.Operator != "tester@host" {
+					t.Errorf("MigrationRecord.Operator = %q, want %q", rec.Operator, "tester@host")
+				}
+				if rec.FinishedAt.Before(rec.StartedAt) || rec.Duration != rec.FinishedAt.Sub(rec.StartedAt) {
+					t.Errorf("MigrationRecord timing = %v to %v (%v), want FinishedAt - StartedAt", rec.StartedAt, rec.FinishedAt, rec.Duration)
+				}
+				if (rec.Outcome == MigrationFailed) != (rec.Error != "") {
+					t.Errorf("MigrationRecord.Error = %q for outcome %s", rec.Error, rec.Outcome)
+				}
+			}
+			if !reflect.DeepEqual(gotAudit, tt.wantAudit) {
+				t.Errorf("audit log = %v, want %v", gotAudit, tt.wantAudit)
+			}
+		})
+	}
+}
@@ -10,6 +10,12 @@ type Migrator interface {
 	// MigrateUpData applies all up migrations for the database data.
 	MigrateUpData(ctx context.Context, sourceURL string) error
 
+	// SchemaStatus reports the current version, dirty flag and pending migrations for the database schema.
+	SchemaStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error)
+
+	// DataStatus reports the current version, dirty flag and pending migrations for the database data.
+	DataStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error)
+
 	// MigrateDropSchema drops the database schema.
 	MigrateDropSchema(ctx context.Context) error
 }
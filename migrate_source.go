@@ -0,0 +1,57 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// openSource opens the migrations at sourceURL. When fsys is set, sourceURL is instead
+// the path of a directory within fsys, e.g. "migrations/schema" of an embed.FS.
+func openSource(fsys fs.FS, sourceURL string) (source.Driver, error) {
+	if fsys != nil {
+		src, err := iofs.New(fsys, sourceURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "iofs.New(): %s", sourceURL)
+		}
+
+		return src, nil
+	}
+
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "source.Open(): %s", sourceURL)
+	}
+
+	return src, nil
+}
+
+// hashSource writes the version, identifier and content of each up migration at sourceURL to w
+func hashSource(w io.Writer, fsys fs.FS, sourceURL string) error {
+	src, err := openSource(fsys, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for ; err == nil; version, err = src.Next(version) {
+		migr, identifier, readErr := readUpMigration(src, version)
+		if readErr != nil && !errors.Is(readErr, fs.ErrNotExist) {
+			return readErr
+		}
+		fmt.Fprintf(w, "%d %s %d\n", version, identifier, len(migr))
+		if _, err := w.Write(migr); err != nil {
+			return errors.Wrap(err, "io.Writer.Write()")
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrapf(err, "source.Driver.Next(): %s", sourceURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package dbinitiator
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_openSource(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/000001_create_accounts.up.sql":   {Data: []byte("CREATE TABLE Accounts (Id INT64) PRIMARY KEY (Id);")},
+		"migrations/000001_create_accounts.down.sql": {Data: []byte("DROP TABLE Accounts;")},
+		"migrations/README.md":                       {Data: []byte("not a migration")},
+	}
+
+	tests := []struct {
+		name      string
+		fsys      fs.FS
+		sourceURL string
+		wantFirst uint
+		wantErr   bool
+	}{
+		{
+			name:      "directory in fs",
+			fsys:      fsys,
+			sourceURL: "migrations",
+			wantFirst: 1,
+		},
+		{
+			name:      "missing directory in fs",
+			fsys:      fsys,
+			sourceURL: "migrations_does_not_exist",
+			wantErr:   true,
+		},
+		{
+			name:      "file url",
+			sourceURL: "file://testdata/spanner/migrations_versioned",
+			wantFirst: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			src, err := openSource(tt.fsys, tt.sourceURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer src.Close()
+
+			first, err := src.First()
+			if err != nil {
+				t.Fatalf("source.Driver.First() error = %v", err)
+			}
+			if first != tt.wantFirst {
+				t.Errorf("source.Driver.First() = %d, want %d", first, tt.wantFirst)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// MigrationStatus reports where a single migrations track (schema or data) currently is
+type MigrationStatus struct {
+	// MigrationsTable is the table the track stores its version in
+	MigrationsTable string
+
+	// SourceURL is the migrations source the pending migrations were read from
+	SourceURL string
+
+	// HasVersion is false if no migration has been applied yet
+	HasVersion bool
+
+	// Version is the currently applied version. It is only valid when HasVersion is true.
+	Version uint
+
+	// Dirty is true if the migration for Version failed part way through
+	Dirty bool
+
+	// Pending lists the up migrations from SourceURL that have not been applied, in the order they will run.
+	// When Dirty is true, the migration for Version is listed first, as it did not finish.
+	Pending []PendingMigration
+}
+
+// PendingMigration is an up migration that has not been applied yet
+type PendingMigration struct {
+	Version    uint
+	Identifier string
+}
+
+// String returns the migration in the form <version>_<identifier>
+func (p PendingMigration) String() string {
+	return fmt.Sprintf("%d_%s", p.Version, p.Identifier)
+}
+
+// UpToDate reports whether the track is clean and has no pending migrations
+func (s *MigrationStatus) UpToDate() bool {
+	return !s.Dirty && len(s.Pending) == 0
+}
+
+// String renders a readable report of the track, suitable for deploy logs
+func (s *MigrationStatus) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: ", s.MigrationsTable)
+	switch {
+	case !s.HasVersion:
+		b.WriteString("no migrations applied")
+	case s.Dirty:
+		fmt.Fprintf(&b, "version %d (dirty)", s.Version)
+	default:
+		fmt.Fprintf(&b, "version %d", s.Version)
+	}
+
+	if len(s.Pending) == 0 {
+		b.WriteString(", no pending migrations\n")
+
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ", %d pending migration(s) from %s:\n", len(s.Pending), s.SourceURL)
+	for _, p := range s.Pending {
+		fmt.Fprintf(&b, "  %s\n", p)
+	}
+
+	return b.String()
+}
+
+// newMigrationStatus reads the current version from m and the pending migrations from src
+func newMigrationStatus(m *migrate.Migrate, src source.Driver, migrationsTable, sourceURL string) (*MigrationStatus, error) {
+	status := &MigrationStatus{
+		MigrationsTable: migrationsTable,
+		SourceURL:       sourceURL,
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+	case err != nil:
+		return nil, errors.Wrap(err, "migrate.Migrate.Version()")
+	default:
+		status.HasVersion = true
+		status.Version = version
+		status.Dirty = dirty
+	}
+
+	status.Pending, err = pendingMigrations(src, status.HasVersion, status.Version, status.Dirty)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// pendingMigrations returns the up migrations in src that come after version, starting at version when it is dirty
+func pendingMigrations(src source.Driver, hasVersion bool, version uint, dirty bool) ([]PendingMigration, error) {
+	var pending []PendingMigration
+
+	v, err := src.First()
+	for ; err == nil; v, err = src.Next(v) {
+		if hasVersion && (v < version || v == version && !dirty) {
+			continue
+		}
+
+		r, identifier, err := src.ReadUp(v)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "source.Driver.ReadUp(): version %d", v)
+		}
+		_ = r.Close()
+
+		pending = append(pending, PendingMigration{Version: v, Identifier: identifier})
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.Wrap(err, "source.Driver.Next()")
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,138 @@
+package dbinitiator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+func Test_pendingMigrations(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		sourceURL  string
+		hasVersion bool
+		version    uint
+		dirty      bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want []PendingMigration
+	}{
+		{
+			name: "no version applied",
+			args: args{
+				sourceURL: "file://testdata/spanner/migrations_versioned",
+			},
+			want: []PendingMigration{
+				{Version: 1, Identifier: "create_accounts"},
+				{Version: 2, Identifier: "add_accounts_email"},
+				{Version: 3, Identifier: "create_accounts_email_index"},
+			},
+		},
+		{
+			name: "partially applied",
+			args: args{
+				sourceURL:  "file://testdata/spanner/migrations_versioned",
+				hasVersion: true,
+				version:    1,
+			},
+			want: []PendingMigration{
+				{Version: 2, Identifier: "add_accounts_email"},
+				{Version: 3, Identifier: "create_accounts_email_index"},
+			},
+		},
+		{
+			name: "dirty version",
+			args: args{
+				sourceURL:  "file://testdata/spanner/migrations_versioned",
+				hasVersion: true,
+				version:    2,
+				dirty:      true,
+			},
+			want: []PendingMigration{
+				{Version: 2, Identifier: "add_accounts_email"},
+				{Version: 3, Identifier: "create_accounts_email_index"},
+			},
+		},
+		{
+			name: "fully applied",
+			args: args{
+				sourceURL:  "file://testdata/spanner/migrations_versioned",
+				hasVersion: true,
+				version:    3,
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			src, err := source.Open(tt.args.sourceURL)
+			if err != nil {
+				t.Fatalf("source.Open() error = %v", err)
+			}
+			defer src.Close()
+
+			got, err := pendingMigrations(src, tt.args.hasVersion, tt.args.version, tt.args.dirty)
+			if err != nil {
+				t.Fatalf("pendingMigrations() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pendingMigrations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationStatus_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status *MigrationStatus
+		want   string
+	}{
+		{
+			name: "no migrations applied",
+			status: &MigrationStatus{
+				MigrationsTable: "SchemaMigrations",
+				SourceURL:       "file://migrations",
+				Pending:         []PendingMigration{{Version: 1, Identifier: "users"}},
+			},
+			want: "SchemaMigrations: no migrations applied, 1 pending migration(s) from file://migrations:\n  1_users\n",
+		},
+		{
+			name: "up to date",
+			status: &MigrationStatus{
+				MigrationsTable: "DataMigrations",
+				SourceURL:       "file://datamigrations",
+				HasVersion:      true,
+				Version:         4,
+			},
+			want: "DataMigrations: version 4, no pending migrations\n",
+		},
+		{
+			name: "dirty with pending migrations",
+			status: &MigrationStatus{
+				MigrationsTable: "schema_migrations",
+				SourceURL:       "file://migrations",
+				HasVersion:      true,
+				Version:         2,
+				Dirty:           true,
+				Pending:         []PendingMigration{{Version: 2, Identifier: "table"}, {Version: 3, Identifier: "index"}},
+			},
+			want: "schema_migrations: version 2 (dirty), 2 pending migration(s) from file://migrations:\n  2_table\n  3_index\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.status.String(); got != tt.want {
+				t.Errorf("MigrationStatus.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
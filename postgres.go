@@ -3,12 +3,18 @@ package dbinitiator
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/go-playground/errors/v5"
 	shopspring "github.com/jackc/pgx-shopspring-decimal"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/moby/moby/api/types/network"
 
@@ -42,26 +48,96 @@ const (
 	defaultPostgresDatabase = "postgres"
 )
 
-// PostgresContainer represents a docker container running a postgres instance.
+// PostgresContainer represents a docker container running a postgres instance,
+// or a postgres server attached with [ConnectPostgres].
 type PostgresContainer struct {
 	testcontainers.Container
 	host                 string
 	port                 network.Port
+	sslMode              SSLMode
 	superUsername        string
+	superPassword        string
 	unprivilegedUsername string
 	password             string
 	defaultDatabase      string
+	extensions           []string
+	encoding             string
+	locale               string
+	template             string
 
 	sMu                  sync.Mutex
 	superUserConnections map[string]*pgxpool.Pool
 
 	muReplacementCount sync.Mutex
 	replacementCount   int
+
+	tMu       sync.Mutex
+	templates map[string]*postgresTemplateState
+}
+
+type postgresContainerConfig struct {
+	password             string
+	unprivilegedUsername string
+	settings             map[string]string
+	extensions           []string
+	encoding             string
+	locale               string
+}
+
+// PostgresContainerOption configures the container started by [NewPostgresContainer]
+type PostgresContainerOption func(*postgresContainerConfig)
+
+// WithPostgresPassword sets the password of both the super user and the unprivileged user. The default is "password".
+func WithPostgresPassword(password string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.password = password
+	}
+}
+
+// WithPostgresUnprivilegedUser sets the user that owns the databases and schemas created by
+// [PostgresContainer.CreateDatabase]. The default is "unprivileged".
+func WithPostgresUnprivilegedUser(username string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.unprivilegedUsername = username
+	}
+}
+
+// WithPostgresSetting passes a server setting to postgres with -c, e.g. WithPostgresSetting("fsync", "off").
+// The only setting by default is max_connections=250, which can be overridden.
+func WithPostgresSetting(name, value string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.settings[name] = value
+	}
+}
+
+// WithPostgresExtensions sets the extensions created in the public schema of each database created by
+// [PostgresContainer.CreateDatabase], e.g. "pg_trgm", "uuid-ossp" and "citext". It replaces the default, which is
+// "btree_gist". Each extension is created at the default version of the image.
+func WithPostgresExtensions(extensions ...string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.extensions = extensions
+	}
+}
+
+// WithPostgresLocale sets LC_COLLATE and LC_CTYPE of each database created by [PostgresContainer.CreateDatabase].
+// The default is "en_US.utf8". The locale must exist in the image.
+func WithPostgresLocale(locale string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.locale = locale
+	}
+}
+
+// WithPostgresEncoding sets the encoding of each database created by [PostgresContainer.CreateDatabase].
+// The default is "UTF8".
+func WithPostgresEncoding(encoding string) PostgresContainerOption {
+	return func(c *postgresContainerConfig) {
+		c.encoding = encoding
+	}
 }
 
 // NewPostgresContainer returns a new PostgresContainer ready to use with postgres.
-func NewPostgresContainer(ctx context.Context, imageVersion string) (*PostgresContainer, error) {
-	pg, err := initPostgresContainer(ctx, imageVersion)
+func NewPostgresContainer(ctx context.Context, imageVersion string, options ...PostgresContainerOption) (*PostgresContainer, error) {
+	pg, err := initPostgresContainer(ctx, imageVersion, newPostgresContainerConfig(options))
 	if err != nil {
 		return nil, err
 	}
@@ -73,17 +149,100 @@ func NewPostgresContainer(ctx context.Context, imageVersion string) (*PostgresCo
 	return pg, nil
 }
 
+// ConnectPostgres returns a PostgresContainer for a postgres server that is already running, e.g. a CI job service,
+// without starting a container. dsn must connect as a user that can create databases and roles. The unprivileged user
+// is created unless it already exists, in which case its password must match [WithPostgresPassword].
+// [WithPostgresSetting] does not apply, and [PostgresContainer.Terminate] does nothing.
+// The sslmode of dsn is kept for every connection opened later.
+func ConnectPostgres(ctx context.Context, dsn string, options ...PostgresContainerOption) (*PostgresContainer, error) {
+	sslMode, err := dsnSSLMode(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "pgx.ParseConfig()")
+	}
+
+	port, ok := network.PortFrom(config.Port, network.TCP)
+	if !ok {
+		return nil, errors.Newf("invalid port %d in dsn", config.Port)
+	}
+
+	pg := newPostgresContainer(nil, config.Host, port, newPostgresContainerConfig(options))
+	pg.superUsername = config.User
+	pg.superPassword = config.Password
+	if config.Database != "" {
+		pg.defaultDatabase = config.Database
+	}
+	pg.sslMode = sslMode
+
+	if err := pg.addUnprivilegedUser(ctx); err != nil {
+		pg.Close()
+
+		return nil, err
+	}
+
+	return pg, nil
+}
+
+// dsnSSLMode returns the sslmode of dsn, given either as a URL or as keyword/value pairs. Without one, it falls back
+// to the PGSSLMODE environment variable and then to [SSLModePrefer], as pgx does.
+func dsnSSLMode(dsn string) (SSLMode, error) {
+	var sslMode string
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", errors.Wrap(err, "url.Parse()")
+		}
+		sslMode = u.Query().Get("sslmode")
+	} else {
+		for _, field := range strings.Fields(dsn) {
+			if value, ok := strings.CutPrefix(field, "sslmode="); ok {
+				sslMode = strings.Trim(value, "'")
+			}
+		}
+	}
+
+	if sslMode == "" {
+		sslMode = os.Getenv("PGSSLMODE")
+	}
+	if sslMode == "" {
+		return SSLModePrefer, nil
+	}
+
+	return SSLMode(sslMode), nil
+}
+
+func newPostgresContainerConfig(options []PostgresContainerOption) *postgresContainerConfig {
+	conf := &postgresContainerConfig{
+		password:             "password",
+		unprivilegedUsername: "unprivileged",
+		settings:             map[string]string{"max_connections": "250"},
+		extensions:           []string{"btree_gist"},
+	}
+	for _, o := range options {
+		o(conf)
+	}
+
+	return conf
+}
+
 // initPostgresContainer returns a PostgresContainer which represents a newly started docker container running postgres.
-func initPostgresContainer(ctx context.Context, imageVersion string) (*PostgresContainer, error) {
-	password := "password"
+func initPostgresContainer(ctx context.Context, imageVersion string, conf *postgresContainerConfig) (*PostgresContainer, error) {
+	cmd := []string{"postgres"}
+	for _, name := range slices.Sorted(maps.Keys(conf.settings)) {
+		cmd = append(cmd, "-c", name+"="+conf.settings[name])
+	}
 
 	req := testcontainers.ContainerRequest{
 		Image:        "postgres:" + imageVersion,
-		Cmd:          []string{"postgres", "-c", "max_connections=250"},
+		Cmd:          cmd,
 		WaitingFor:   wait.ForLog(" UTC [1] LOG:  database system is ready to accept connections"),
 		ExposedPorts: []string{defaultPostgresPort},
 		Env: map[string]string{
-			"POSTGRES_PASSWORD": password,
+			"POSTGRES_PASSWORD": conf.password,
 		},
 	}
 
@@ -100,16 +259,40 @@ func initPostgresContainer(ctx context.Context, imageVersion string) (*PostgresC
 		return nil, errors.Wrapf(err, "failed to get external port for exposed port %s", defaultPostgresPort)
 	}
 
-	return &PostgresContainer{
-		Container:            postgresC,
-		host:                 defaultPostgresHost,
-		port:                 externalPort,
+	return newPostgresContainer(postgresC, defaultPostgresHost, externalPort, conf), nil
+}
+
+// newPostgresContainer returns a PostgresContainer for the server at host and port, using the super user of the
+// postgres image
+func newPostgresContainer(container testcontainers.Container, host string, port network.Port, conf *postgresContainerConfig) *PostgresContainer {
+	pc := &PostgresContainer{
+		Container:            container,
+		host:                 host,
+		port:                 port,
+		sslMode:              SSLModeDisable,
 		superUserConnections: make(map[string]*pgxpool.Pool, 0),
 		superUsername:        "postgres",
-		unprivilegedUsername: "unprivileged",
-		password:             password,
+		superPassword:        conf.password,
+		unprivilegedUsername: conf.unprivilegedUsername,
+		password:             conf.password,
 		defaultDatabase:      defaultPostgresDatabase,
-	}, nil
+		extensions:           conf.extensions,
+		encoding:             "UTF8",
+		locale:               "en_US.utf8",
+	}
+
+	// a locale or encoding other than the one of template1 can only be set when copying template0
+	if conf.locale != "" || conf.encoding != "" {
+		pc.template = "template0"
+	}
+	if conf.locale != "" {
+		pc.locale = conf.locale
+	}
+	if conf.encoding != "" {
+		pc.encoding = conf.encoding
+	}
+
+	return pc
 }
 
 // CreateDatabase creates a new database with the given name and returns a connection to it.
@@ -120,35 +303,38 @@ func (pc *PostgresContainer) CreateDatabase(ctx context.Context, dbName string)
 		return nil, err
 	}
 
+	template := ""
+	if pc.template != "" {
+		template = fmt.Sprintf("TEMPLATE = %q", pc.template)
+	}
+
 	_, err = db.Exec(ctx, fmt.Sprintf(`
 		CREATE DATABASE %q WITH
 			OWNER = %q
-			ENCODING = 'UTF8'
-			LC_COLLATE = 'en_US.utf8'
-			LC_CTYPE = 'en_US.utf8'
+			%s
+			ENCODING = '%s'
+			LC_COLLATE = '%s'
+			LC_CTYPE = '%s'
 			TABLESPACE = pg_default
 			CONNECTION LIMIT = -1;
-	`, dbName, pc.unprivilegedUsername))
+	`, dbName, pc.unprivilegedUsername, template, pc.encoding, pc.locale, pc.locale))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create database=%q", dbName)
 	}
 
-	// create extension in the newly created table
-	db, err = openDB(ctx, PostgresConnStr(pc.superUsername, pc.password, pc.host, pc.port.Port(), dbName, SSLModeDisable))
+	// create extensions in the newly created database
+	db, err = openDB(ctx, PostgresConnStr(pc.superUsername, pc.superPassword, pc.host, pc.port.Port(), dbName, pc.sslMode))
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
-	_, err = db.Exec(ctx, `
-		CREATE EXTENSION IF NOT EXISTS btree_gist
-			SCHEMA public
-			VERSION "1.5";
-	`)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create extension btree_gist in database=%q", dbName)
+	for _, extension := range pc.extensions {
+		if _, err := db.Exec(ctx, fmt.Sprintf(`CREATE EXTENSION IF NOT EXISTS %q SCHEMA public;`, extension)); err != nil {
+			return nil, errors.Wrapf(err, "failed to create extension %s in database=%q", extension, dbName)
+		}
 	}
 
-	u, err := openDB(ctx, PostgresConnStr(pc.unprivilegedUsername, pc.password, pc.host, pc.port.Port(), dbName, SSLModeDisable))
+	u, err := openDB(ctx, PostgresConnStr(pc.unprivilegedUsername, pc.password, pc.host, pc.port.Port(), dbName, pc.sslMode))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to connect to database=%q with %s", dbName, pc.unprivilegedUsername)
 	}
@@ -163,10 +349,37 @@ func (pc *PostgresContainer) CreateDatabase(ctx context.Context, dbName string)
 		Pool:    u,
 		dbName:  dbName,
 		schema:  pc.unprivilegedUsername,
-		connStr: PostgresConnStr(pc.unprivilegedUsername, pc.password, pc.host, pc.port.Port(), dbName, SSLModeDisable),
+		connStr: PostgresConnStr(pc.unprivilegedUsername, pc.password, pc.host, pc.port.Port(), dbName, pc.sslMode),
 	}, nil
 }
 
+// dropDatabase drops the database with dbName, closing any connections to it
+func (pc *PostgresContainer) dropDatabase(ctx context.Context, dbName string) error {
+	db, err := pc.superUserConnection(ctx, pc.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %q WITH (FORCE)", dbName)); err != nil {
+		return errors.Wrapf(err, "failed to drop database=%q", dbName)
+	}
+
+	return nil
+}
+
+// Terminate stops and removes the container. It does nothing for a server attached with [ConnectPostgres].
+func (pc *PostgresContainer) Terminate(ctx context.Context, opts ...testcontainers.TerminateOption) error {
+	if pc == nil || pc.Container == nil {
+		return nil
+	}
+
+	if err := pc.Container.Terminate(ctx, opts...); err != nil {
+		return errors.Wrap(err, "testcontainers.Container.Terminate()")
+	}
+
+	return nil
+}
+
 // Close closes all connections to the postgres instance
 func (pc *PostgresContainer) Close() {
 	for _, pool := range pc.superUserConnections {
@@ -182,7 +395,7 @@ func (pc *PostgresContainer) superUserConnection(ctx context.Context, database s
 	pool, ok := pc.superUserConnections[database]
 	if !ok || pool == nil || pool.Ping(ctx) != nil {
 		var err error
-		pool, err = openDB(ctx, PostgresConnStr(pc.superUsername, pc.password, pc.host, pc.port.Port(), database, SSLModeDisable))
+		pool, err = openDB(ctx, PostgresConnStr(pc.superUsername, pc.superPassword, pc.host, pc.port.Port(), database, pc.sslMode))
 		if err != nil {
 			return nil, err
 		}
@@ -208,6 +421,13 @@ func (pc *PostgresContainer) addUnprivilegedUser(ctx context.Context) error {
 			CONNECTION LIMIT -1
 			PASSWORD '%s';
 	`, pc.unprivilegedUsername, pc.password)); err != nil {
+		// an attached server may already have the user, possibly created concurrently by another test package,
+		// which fails with duplicate_object (42710) or unique_violation (23505)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (pgErr.Code == "42710" || pgErr.Code == "23505") {
+			return nil
+		}
+
 		return errors.Wrap(err, "failed to create unprivileged user")
 	}
 
@@ -240,14 +460,15 @@ func PostgresConnStr(username, password, host, port, database string, sslMode SS
 		sslMode = SSLModeRequire
 	}
 
-	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=%s",
-		username,
-		password,
-		host,
-		port,
-		database,
-		string(sslMode),
-	)
+	u := &url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(username, password),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + database,
+		RawQuery: "sslmode=" + url.QueryEscape(string(sslMode)),
+	}
+
+	return u.String()
 }
 
 func openDB(ctx context.Context, connectionString string) (*pgxpool.Pool, error) {
@@ -0,0 +1,177 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WithAuditLog writes a [MigrationRecord] each time a migration is run to an audit table named after the
+// migrations table with an "_audit" suffix, e.g. "schema_migrations_audit".
+// Read it back with [PostgresMigrator.SchemaAuditLog] and [PostgresMigrator.DataAuditLog].
+func (p *PostgresMigrator) WithAuditLog() *PostgresMigrator {
+	p.auditLog = true
+
+	return p
+}
+
+// WithOperator sets the operator recorded in the audit log. It defaults to <user>@<host> of the current process.
+func (p *PostgresMigrator) WithOperator(operator string) *PostgresMigrator {
+	p.operator = operator
+
+	return p
+}
+
+// SchemaAuditLog returns the audit log of the schema migrations, in the order they were run
+func (p *PostgresMigrator) SchemaAuditLog(ctx context.Context) ([]MigrationRecord, error) {
+	records, err := p.auditRecords(ctx, p.schemaMigrationsTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresMigrator.auditRecords()")
+	}
+
+	return records, nil
+}
+
+// DataAuditLog returns the audit log of the data migrations, in the order they were run
+func (p *PostgresMigrator) DataAuditLog(ctx context.Context) ([]MigrationRecord, error) {
+	records, err := p.auditRecords(ctx, p.dataMigrationsTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresMigrator.auditRecords()")
+	}
+
+	return records, nil
+}
+
+// auditRecords reads the audit log of migrationsTable. It is empty when the audit table was never created.
+func (p *PostgresMigrator) auditRecords(ctx context.Context, migrationsTable string) ([]MigrationRecord, error) {
+	table, err := quoteMigrationsTable(migrationsTable + "_audit")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return nil, err
+	}
+	audit := &postgresAuditLog{db: db, table: table}
+	defer audit.close()
+
+	var exists bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+		return nil, errors.Wrapf(err, "pgxpool.Pool.QueryRow(): %s", table)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	return audit.records(ctx)
+}
+
+func (p *PostgresMigrator) operatorName() string {
+	if p.operator != "" {
+		return p.operator
+	}
+
+	return defaultOperator()
+}
+
+// postgresAuditLog stores the audit log of a track in a Postgres table
+type postgresAuditLog struct {
+	db    *pgxpool.Pool
+	table string
+}
+
+var _ migrationAuditLog = (*postgresAuditLog)(nil)
+
+// newAuditLog returns the audit log of migrationsTable, creating its table if it does not exist
+func (p *PostgresMigrator) newAuditLog(ctx context.Context, migrationsTable string) (*postgresAuditLog, error) {
+	table, err := quoteMigrationsTable(migrationsTable + "_audit")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &postgresAuditLog{db: db, table: table}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		version bigint NOT NULL,
+		identifier text NOT NULL,
+		direction text NOT NULL,
+		started_at timestamptz NOT NULL,
+		finished_at timestamptz NOT NULL,
+		duration_ns bigint NOT NULL,
+		operator text NOT NULL,
+		outcome text NOT NULL,
+		error text
+	)`, a.table)); err != nil {
+		db.Close()
+
+		return nil, errors.Wrapf(err, "pgxpool.Pool.Exec(): create %s", a.table)
+	}
+
+	return a, nil
+}
+
+func (a *postgresAuditLog) append(ctx context.Context, rec *MigrationRecord) error {
+	var recErr *string
+	if rec.Error != "" {
+		recErr = &rec.Error
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (version, identifier, direction, started_at, finished_at, duration_ns, operator, outcome, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, a.table)
+	if _, err := a.db.Exec(ctx, query,
+		int64(rec.Version), rec.Identifier, string(rec.Direction), rec.StartedAt, rec.FinishedAt, rec.Duration.Nanoseconds(), rec.Operator, string(rec.Outcome), recErr,
+	); err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Exec()")
+	}
+
+	return nil
+}
+
+func (a *postgresAuditLog) records(ctx context.Context) ([]MigrationRecord, error) {
+	rows, err := a.db.Query(ctx, fmt.Sprintf(`
+		SELECT version, identifier, direction, started_at, finished_at, duration_ns, operator, outcome, error
+		FROM %s
+		ORDER BY id`, a.table))
+	if err != nil {
+		return nil, errors.Wrap(err, "pgxpool.Pool.Query()")
+	}
+	defer rows.Close()
+
+	var records []MigrationRecord
+	for rows.Next() {
+		var version, durationNs int64
+		var direction, outcome string
+		var recErr *string
+		var rec MigrationRecord
+		if err := rows.Scan(&version, &rec.Identifier, &direction, &rec.StartedAt, &rec.FinishedAt, &durationNs, &rec.Operator, &outcome, &recErr); err != nil {
+			return nil, errors.Wrap(err, "pgx.Rows.Scan()")
+		}
+		rec.Version = uint(version)
+		rec.Direction = MigrationDirection(direction)
+		rec.Duration = time.Duration(durationNs)
+		rec.Outcome = MigrationOutcome(outcome)
+		if recErr != nil {
+			rec.Error = *recErr
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "pgx.Rows.Err()")
+	}
+
+	return records, nil
+}
+
+func (a *postgresAuditLog) close() {
+	a.db.Close()
+}
@@ -2,9 +2,11 @@ package dbinitiator
 
 import (
 	"context"
+	"io/fs"
+	"strings"
 
 	"github.com/go-playground/errors/v5"
-	"github.com/golang-migrate/migrate/v4"
+	postgresDriver "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,9 +14,12 @@ import (
 // PostgresDatabase represents a postgres database created and ready for migrations
 type PostgresDatabase struct {
 	*pgxpool.Pool
-	dbName  string
-	schema  string
-	connStr string
+	dbName       string
+	schema       string
+	connStr      string
+	resetVersion bool
+	resetSeqs    bool
+	sourceFS     fs.FS
 }
 
 // NewPostgresDatabase creates a new database and schema, then connects to it.
@@ -65,29 +70,73 @@ func (db *PostgresDatabase) Schema() string {
 	return db.schema
 }
 
-// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's
+// WithVersionReset makes MigrateUp clear the migration version before applying each source.
+// This allows several independent sources, each numbered from 1, to be applied in sequence.
+func (db *PostgresDatabase) WithVersionReset() *PostgresDatabase {
+	db.resetVersion = true
+
+	return db
+}
+
+// WithSequenceReset makes Truncate restart the sequences in the schema, so generated ids start over.
+func (db *PostgresDatabase) WithSequenceReset() *PostgresDatabase {
+	db.resetSeqs = true
+
+	return db
+}
+
+// WithSourceFS reads migrations from fsys, e.g. an embed.FS compiled into the test binary.
+// The sourceURL of every migrate method is then the path of a migrations directory within fsys.
+func (db *PostgresDatabase) WithSourceFS(fsys fs.FS) *PostgresDatabase {
+	db.sourceFS = fsys
+
+	return db
+}
+
+// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's.
+// A *DirtyError is returned if a previous migration failed part way through.
+//
+// The version carries over from one source to the next, so an error is returned for a source that ends at or before
+// the current version. Use WithVersionReset to apply several sources that are each numbered from 1.
 func (db *PostgresDatabase) MigrateUp(sourceURL ...string) error {
 	for _, source := range sourceURL {
-		m, err := migrate.New(source, db.connStr)
-		if err != nil {
-			return errors.Wrapf(err, "migrate.New(): fileURL=%s and connectionURL=%s", source, db.connStr)
+		if err := db.migrateUp(source); err != nil {
+			return err
 		}
+	}
 
-		if _, _, err := m.Version(); err == nil {
-			if err := m.Force(-1); err != nil {
-				return errors.Wrapf(err, "migrate.Migrate.Force(): %s", source)
-			}
-		}
+	return nil
+}
 
-		if err := m.Up(); err != nil {
-			return errors.Wrapf(err, "migrate.Migrate.Up(): %s", source)
-		}
+func (db *PostgresDatabase) migrateUp(source string) error {
+	m, err := newPostgresMigrate(db.sourceFS, source, db.connStr)
+	if err != nil {
+		return err
+	}
 
-		if err, dbErr := m.Close(); err != nil {
-			return errors.Wrapf(err, "migrate.Migrate.Close(): source error: %s", source)
-		} else if dbErr != nil {
-			return errors.Wrapf(dbErr, "migrate.Migrate.Close(): database error: %s", source)
+	if db.resetVersion {
+		if err := resetVersion(m, postgresDriver.DefaultMigrationsTable); err != nil {
+			_, _ = m.Close()
+
+			return errors.Wrapf(err, "resetVersion(): %s", source)
 		}
+	} else if err := checkSourceAhead(m, db.sourceFS, source); err != nil {
+		_, _ = m.Close()
+
+		return err
+	}
+
+	if err := m.Up(); err != nil {
+		err = dirtyError(m, postgresDriver.DefaultMigrationsTable, err)
+		_, _ = m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Up(): %s", source)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Close(): source error: %s", source)
+	} else if dbErr != nil {
+		return errors.Wrapf(dbErr, "migrate.Migrate.Close(): database error: %s", source)
 	}
 
 	return nil
@@ -95,12 +144,15 @@ func (db *PostgresDatabase) MigrateUp(sourceURL ...string) error {
 
 // MigrateDown will migrate all the way down
 func (db *PostgresDatabase) MigrateDown(sourceURL string) error {
-	m, err := migrate.New(sourceURL, db.connStr)
+	m, err := newPostgresMigrate(db.sourceFS, sourceURL, db.connStr)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create new migrate with fileURL=%s and connectionURL=%s", sourceURL, db.connStr)
+		return err
 	}
 
 	if err := m.Down(); err != nil {
+		err = dirtyError(m, postgresDriver.DefaultMigrationsTable, err)
+		_, _ = m.Close()
+
 		return errors.Wrap(err, "migrate.Migrate.Down()")
 	}
 
@@ -113,6 +165,174 @@ func (db *PostgresDatabase) MigrateDown(sourceURL string) error {
 	return nil
 }
 
+// MigrateTo will migrate up or down to version
+func (db *PostgresDatabase) MigrateTo(sourceURL string, version uint) error {
+	m, err := newPostgresMigrate(db.sourceFS, sourceURL, db.connStr)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Migrate(version); err != nil {
+		err = dirtyError(m, postgresDriver.DefaultMigrationsTable, err)
+		_, _ = m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Migrate(): version %d", version)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Close(): source error")
+	} else if dbErr != nil {
+		return errors.Wrap(dbErr, "migrate.Migrate.Close(): database error")
+	}
+
+	return nil
+}
+
+// MigrateSteps will apply n migrations. A negative n migrates down.
+func (db *PostgresDatabase) MigrateSteps(sourceURL string, n int) error {
+	m, err := newPostgresMigrate(db.sourceFS, sourceURL, db.connStr)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Steps(n); err != nil {
+		err = dirtyError(m, postgresDriver.DefaultMigrationsTable, err)
+		_, _ = m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Steps(): n=%d", n)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Close(): source error")
+	} else if dbErr != nil {
+		return errors.Wrap(dbErr, "migrate.Migrate.Close(): database error")
+	}
+
+	return nil
+}
+
+// ForceVersion sets the migration version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (db *PostgresDatabase) ForceVersion(sourceURL string, version int) error {
+	m, err := newPostgresMigrate(db.sourceFS, sourceURL, db.connStr)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Force(version); err != nil {
+		_, _ = m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Force(): version %d", version)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Close(): source error")
+	} else if dbErr != nil {
+		return errors.Wrap(dbErr, "migrate.Migrate.Close(): database error")
+	}
+
+	return nil
+}
+
+// RetryDirty runs the failed up migration that left the database dirty again.
+// Postgres applies a migration file as a single implicit transaction, so it can usually be retried once the cause is fixed.
+func (db *PostgresDatabase) RetryDirty(sourceURL string) error {
+	m, err := newPostgresMigrate(db.sourceFS, sourceURL, db.connStr)
+	if err != nil {
+		return err
+	}
+
+	src, err := openSource(db.sourceFS, sourceURL)
+	if err != nil {
+		_, _ = m.Close()
+
+		return err
+	}
+	defer src.Close()
+
+	if err := retryDirty(m, src); err != nil {
+		err = dirtyError(m, postgresDriver.DefaultMigrationsTable, err)
+		_, _ = m.Close()
+
+		return err
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrap(err, "migrate.Migrate.Close(): source error")
+	} else if dbErr != nil {
+		return errors.Wrap(dbErr, "migrate.Migrate.Close(): database error")
+	}
+
+	return nil
+}
+
+// Truncate deletes all rows from every table in the schema and keeps the schema. All tables are truncated in a
+// single statement, so foreign keys between them do not need any order.
+//
+// The migration tables are kept, so the migration versions are unchanged. Force the data version back before
+// re-seeding data migrations.
+func (db *PostgresDatabase) Truncate(ctx context.Context) error {
+	tablesQuery := `
+		SELECT format('%I.%I', schemaname, tablename)
+		FROM pg_tables
+		WHERE schemaname = $1 AND NOT tablename = ANY($2)
+		ORDER BY tablename`
+
+	tables, err := postgresQueryStrings(ctx, db.Pool, tablesQuery, db.schema, postgresTrackingTables(postgresDriver.DefaultMigrationsTable, postgresDataMigrationsTable))
+	if err != nil {
+		return err
+	}
+
+	var stmts []string
+	if len(tables) > 0 {
+		stmt := "TRUNCATE TABLE " + strings.Join(tables, ", ")
+		if db.resetSeqs {
+			stmt += " RESTART IDENTITY"
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	if db.resetSeqs {
+		// sequences owned by a truncated column are restarted by RESTART IDENTITY
+		sequencesQuery := `
+			SELECT format('ALTER SEQUENCE %I.%I RESTART', n.nspname, c.relname)
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1
+				AND c.relkind = 'S'
+				AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype IN ('a', 'i'))
+			ORDER BY c.relname`
+
+		seqStmts, err := postgresQueryStrings(ctx, db.Pool, sequencesQuery, db.schema)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, seqStmts...)
+	}
+
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Begin()")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return errors.Wrapf(err, "pgx.Tx.Exec(): %s", stmt)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "pgx.Tx.Commit()")
+	}
+
+	return nil
+}
+
 // Close closes the database connection
 func (db *PostgresDatabase) Close() {
 	db.Pool.Close()
@@ -0,0 +1,145 @@
+package dbinitiator
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresSchemaObjectsQuery describes every table column, constraint, index, view, sequence, enum type and
+// function in the current schema as a single line each. Objects owned by extensions and the tables in $1, along with
+// their sequences, are ignored. The tables in $1 are quoted names, optionally qualified with a schema, and resolved
+// on the search path as the migrate driver does.
+// Names in the current schema are not qualified, so schemas with different names can be compared.
+const postgresSchemaObjectsQuery = `
+	WITH ignored AS (
+		SELECT to_regclass(t)::oid AS oid
+		FROM unnest($1::text[]) t
+		WHERE to_regclass(t) IS NOT NULL
+	), rel AS (
+		SELECT c.oid, c.relname, c.relkind
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND NOT c.oid IN (SELECT oid FROM ignored)
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+	)
+	SELECT format('COLUMN %I.%I %s%s%s', rel.relname, a.attname, format_type(a.atttypid, a.atttypmod),
+		CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END,
+		COALESCE(' DEFAULT ' || pg_get_expr(ad.adbin, ad.adrelid), ''))
+	FROM rel
+	JOIN pg_attribute a ON a.attrelid = rel.oid
+	LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+	WHERE rel.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+	UNION ALL
+	SELECT format('CONSTRAINT %I ON %I %s', con.conname, rel.relname, pg_get_constraintdef(con.oid))
+	FROM rel
+	JOIN pg_constraint con ON con.conrelid = rel.oid
+	UNION ALL
+	SELECT pg_get_indexdef(i.indexrelid)
+	FROM rel
+	JOIN pg_index i ON i.indrelid = rel.oid
+	WHERE NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid)
+	UNION ALL
+	SELECT format('%s %I AS %s', CASE rel.relkind WHEN 'v' THEN 'VIEW' ELSE 'MATERIALIZED VIEW' END, rel.relname, pg_get_viewdef(rel.oid))
+	FROM rel
+	WHERE rel.relkind IN ('v', 'm')
+	UNION ALL
+	SELECT format('SEQUENCE %I', rel.relname)
+	FROM rel
+	WHERE rel.relkind = 'S'
+		AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.objid = rel.oid AND d.deptype IN ('a', 'i') AND d.refobjid IN (SELECT oid FROM ignored)
+		)
+	UNION ALL
+	SELECT format('TYPE %I AS ENUM (%s)', t.typname, string_agg(quote_literal(e.enumlabel), ', ' ORDER BY e.enumsortorder))
+	FROM pg_type t
+	JOIN pg_namespace n ON n.oid = t.typnamespace
+	JOIN pg_enum e ON e.enumtypid = t.oid
+	WHERE n.nspname = current_schema()
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = t.oid AND d.deptype = 'e')
+	GROUP BY t.typname
+	UNION ALL
+	SELECT pg_get_functiondef(p.oid)
+	FROM pg_proc p
+	JOIN pg_namespace n ON n.oid = p.pronamespace
+	WHERE n.nspname = current_schema()
+		AND p.prokind IN ('f', 'p')
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')`
+
+// SchemaDrift applies the schema migrations from sourceURL to a scratch database in container and compares its
+// catalog with the database the migrator points at. Objects are compared within the current schema of each
+// connection, so the schemas may have different names. Tables used to track migrations are ignored.
+//
+// The scratch database is dropped before returning.
+func (p *PostgresMigrator) SchemaDrift(ctx context.Context, container *PostgresContainer, sourceURL string) (*SchemaDrift, error) {
+	ccclogger.FromCtx(ctx).Infof("Checking schema drift against migrations from %s", sourceURL)
+
+	db, err := container.CreateDatabase(ctx, "drift_"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresContainer.CreateDatabase()")
+	}
+	defer func() {
+		db.Close()
+		_ = container.dropDatabase(context.Background(), db.dbName)
+	}()
+
+	scratch := NewPostgresMigrator(container.unprivilegedUsername, container.password, container.host, container.port.Port(), db.dbName, container.sslMode).
+		WithSchemaMigrationsTable(p.schemaMigrationsTable).
+		WithDataMigrationsTable(p.dataMigrationsTable).
+		WithSourceFS(p.sourceFS)
+
+	if err := scratch.MigrateUpSchema(ctx, sourceURL); err != nil {
+		return nil, errors.Wrap(err, "PostgresMigrator.MigrateUpSchema()")
+	}
+
+	expected, err := scratch.schemaObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := p.schemaObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSchemaDrift(expected, live), nil
+}
+
+// schemaObjects describes the objects in the current schema, without the tables used to track migrations
+func (p *PostgresMigrator) schemaObjects(ctx context.Context) ([]string, error) {
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tracking := postgresTrackingTables(p.schemaMigrationsTable, p.dataMigrationsTable)
+	for i, table := range tracking {
+		if tracking[i], err = quoteMigrationsTable(table); err != nil {
+			return nil, err
+		}
+	}
+
+	return postgresSchemaObjects(ctx, db, tracking)
+}
+
+// postgresTrackingTables returns the migrations tables with the history and audit tables kept alongside them,
+// qualified with a schema as the migrations tables are
+func postgresTrackingTables(migrationsTables ...string) []string {
+	var tables []string
+	for _, table := range migrationsTables {
+		tables = append(tables, table, table+"_history", table+"_audit")
+	}
+
+	return tables
+}
+
+func postgresSchemaObjects(ctx context.Context, db *pgxpool.Pool, ignoreTables []string) ([]string, error) {
+	return postgresQueryStrings(ctx, db, postgresSchemaObjectsQuery, ignoreTables)
+}
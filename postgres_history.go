@@ -0,0 +1,122 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WithChecksumPolicy records a checksum of every migration file as it is applied, in a history table named after
+// the migrations table with a "_history" suffix, e.g. "schema_migrations_history".
+//
+// Before migrating, the files of applied migrations are compared with their recorded checksums and policy decides
+// whether a changed file fails the migration or is logged. Migrations applied before the policy was set have no checksum.
+func (p *PostgresMigrator) WithChecksumPolicy(policy ChecksumPolicy) *PostgresMigrator {
+	p.checksumPolicy = policy
+
+	return p
+}
+
+// verifyChecksums checks the applied migrations of migrationsTable against the files in sourceURL
+func (p *PostgresMigrator) verifyChecksums(ctx context.Context, migrationsTable, sourceURL string) error {
+	if p.checksumPolicy == 0 {
+		return nil
+	}
+
+	history, err := p.newHistory(ctx, migrationsTable)
+	if err != nil {
+		return err
+	}
+	defer history.close()
+
+	src, err := p.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return verifyChecksums(ctx, history, src, migrationsTable, p.checksumPolicy)
+}
+
+// postgresHistory stores migration checksums in a Postgres table
+type postgresHistory struct {
+	db    *pgxpool.Pool
+	table string
+}
+
+var _ migrationHistory = (*postgresHistory)(nil)
+
+// newHistory returns the history of migrationsTable, creating its table if it does not exist
+func (p *PostgresMigrator) newHistory(ctx context.Context, migrationsTable string) (*postgresHistory, error) {
+	table, err := quoteMigrationsTable(migrationsTable + "_history")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &postgresHistory{db: db, table: table}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version bigint NOT NULL PRIMARY KEY,
+		identifier text NOT NULL,
+		checksum text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`, h.table)); err != nil {
+		db.Close()
+
+		return nil, errors.Wrapf(err, "pgxpool.Pool.Exec(): create %s", h.table)
+	}
+
+	return h, nil
+}
+
+func (h *postgresHistory) checksums(ctx context.Context) (map[uint]string, error) {
+	rows, err := h.db.Query(ctx, fmt.Sprintf("SELECT version, checksum FROM %s", h.table))
+	if err != nil {
+		return nil, errors.Wrap(err, "pgxpool.Pool.Query()")
+	}
+	defer rows.Close()
+
+	checksums := make(map[uint]string)
+	for rows.Next() {
+		var version int64
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, errors.Wrap(err, "pgx.Rows.Scan()")
+		}
+		checksums[uint(version)] = checksum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "pgx.Rows.Err()")
+	}
+
+	return checksums, nil
+}
+
+func (h *postgresHistory) record(ctx context.Context, version uint, identifier, checksum string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (version, identifier, checksum) VALUES ($1, $2, $3)
+		ON CONFLICT (version) DO UPDATE SET identifier = EXCLUDED.identifier, checksum = EXCLUDED.checksum, applied_at = now()`, h.table)
+	if _, err := h.db.Exec(ctx, query, int64(version), identifier, checksum); err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Exec()")
+	}
+
+	return nil
+}
+
+func (h *postgresHistory) removeAfter(ctx context.Context, version int) error {
+	if _, err := h.db.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE version > $1", h.table), int64(version)); err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Exec()")
+	}
+
+	return nil
+}
+
+func (h *postgresHistory) close() {
+	h.db.Close()
+}
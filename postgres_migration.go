@@ -2,13 +2,35 @@ package dbinitiator
 
 import (
 	"context"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strings"
 
+	ccclogger "github.com/cccteam/logger"
 	"github.com/go-playground/errors/v5"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	postgresDriver "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// postgresDataMigrationsTable is the default table of the data migrations track. The schema migrations track
+// defaults to [postgresDriver.DefaultMigrationsTable].
+const postgresDataMigrationsTable = "data_migrations"
+
+// PostgresMigrator handles connecting to an existing postgres database and running migrations
 type PostgresMigrator struct {
-	connStr string
+	connStr               string
+	dataMigrationsTable   string
+	schemaMigrationsTable string
+	sourceFS              fs.FS
+	dataMigrationFuncs    map[uint]postgresGoMigration
+	checksumPolicy        ChecksumPolicy
+	auditLog              bool
+	operator              string
+	dropFilter            DropFilter
 }
 
 var _ Migrator = (*PostgresMigrator)(nil)
@@ -19,21 +41,294 @@ var _ Migrator = (*PostgresMigrator)(nil)
 // sslMode sets the sslmode query parameter.
 // Pass an empty string to use the default, which is [SSLModeRequire].
 // Use [SSLModeDisable] only for local test containers.
+//
+// Uses the following tables by default to store migration versions:
+//   - Data Migrations table: "data_migrations"
+//   - Schema Migrations table: "schema_migrations"
 func NewPostgresMigrator(username, password, host, port, database string, sslMode SSLMode) *PostgresMigrator {
 	return &PostgresMigrator{
-		connStr: PostgresConnStr(username, password, host, port, database, sslMode),
+		connStr:               PostgresConnStr(username, password, host, port, database, sslMode),
+		dataMigrationsTable:   postgresDataMigrationsTable,
+		schemaMigrationsTable: postgresDriver.DefaultMigrationsTable,
 	}
 }
 
-// MigrateUp will migrate all the way up, applying all up migrations from the sourceURL
-func (p *PostgresMigrator) MigrateUpSchema(_ context.Context, sourceURL string) error {
-	m, err := migrate.New(sourceURL, p.connStr)
+// WithSchemaMigrationsTable allows setting the schema migration table to be used.
+// The table may be qualified with an existing schema, e.g. "ops.schema_migrations".
+func (p *PostgresMigrator) WithSchemaMigrationsTable(table string) *PostgresMigrator {
+	p.schemaMigrationsTable = table
+
+	return p
+}
+
+// WithDataMigrationsTable allows setting the data migration table to be used.
+// The table may be qualified with an existing schema, e.g. "ops.data_migrations".
+func (p *PostgresMigrator) WithDataMigrationsTable(table string) *PostgresMigrator {
+	p.dataMigrationsTable = table
+
+	return p
+}
+
+// WithSourceFS reads migrations from fsys, e.g. an embed.FS compiled into the binary.
+// The sourceURL of every method is then the path of a migrations directory within fsys.
+func (p *PostgresMigrator) WithSourceFS(fsys fs.FS) *PostgresMigrator {
+	p.sourceFS = fsys
+
+	return p
+}
+
+// WithDropFilter limits MigrateDropSchema and DropSchemaStatements to the tables and views selected by filter
+func (p *PostgresMigrator) WithDropFilter(filter DropFilter) *PostgresMigrator {
+	p.dropFilter = filter
+
+	return p
+}
+
+// MigrateUpSchema will migrate all the way up, applying all up migrations from the sourceURL
+//
+// Use for DDL migrations
+func (p *PostgresMigrator) MigrateUpSchema(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Applying schema migrations from %s", sourceURL)
+	if err := p.migrateUp(ctx, p.schemaMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateUp()")
+	}
+
+	return nil
+}
+
+// MigrateUpData will apply all data migrations from the sourceURL
+//
+// Use for DML migrations. Versions are tracked separately from the schema migrations.
+func (p *PostgresMigrator) MigrateUpData(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Applying data migrations from %s", sourceURL)
+	if err := p.migrateUp(ctx, p.dataMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateUp()")
+	}
+
+	return nil
+}
+
+// MigrateSchemaTo migrates the schema up or down to version, applying the migrations from the sourceURL
+func (p *PostgresMigrator) MigrateSchemaTo(ctx context.Context, sourceURL string, version uint) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating schema to version %d from %s", version, sourceURL)
+	if err := p.migrateTo(ctx, p.schemaMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateTo()")
+	}
+
+	return nil
+}
+
+// MigrateDataTo migrates the data up or down to version, applying the migrations from the sourceURL
+func (p *PostgresMigrator) MigrateDataTo(ctx context.Context, sourceURL string, version uint) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating data to version %d from %s", version, sourceURL)
+	if err := p.migrateTo(ctx, p.dataMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateTo()")
+	}
+
+	return nil
+}
+
+// MigrateSchemaSteps applies n schema migrations from the sourceURL. A negative n migrates down.
+func (p *PostgresMigrator) MigrateSchemaSteps(ctx context.Context, sourceURL string, n int) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating schema %d step(s) from %s", n, sourceURL)
+	if err := p.migrateSteps(ctx, p.schemaMigrationsTable, sourceURL, n); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateSteps()")
+	}
+
+	return nil
+}
+
+// MigrateDataSteps applies n data migrations from the sourceURL. A negative n migrates down.
+func (p *PostgresMigrator) MigrateDataSteps(ctx context.Context, sourceURL string, n int) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating data %d step(s) from %s", n, sourceURL)
+	if err := p.migrateSteps(ctx, p.dataMigrationsTable, sourceURL, n); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.migrateSteps()")
+	}
+
+	return nil
+}
+
+// ForceSchemaVersion sets the schema migrations version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (p *PostgresMigrator) ForceSchemaVersion(ctx context.Context, sourceURL string, version int) error {
+	ccclogger.FromCtx(ctx).Infof("Forcing schema version %d", version)
+	if err := p.forceVersion(ctx, p.schemaMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.forceVersion()")
+	}
+
+	return nil
+}
+
+// ForceDataVersion sets the data migrations version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (p *PostgresMigrator) ForceDataVersion(ctx context.Context, sourceURL string, version int) error {
+	ccclogger.FromCtx(ctx).Infof("Forcing data version %d", version)
+	if err := p.forceVersion(ctx, p.dataMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.forceVersion()")
+	}
+
+	return nil
+}
+
+// RetryDirtySchema runs the failed schema migration that left the schema migrations dirty again.
+func (p *PostgresMigrator) RetryDirtySchema(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Retrying dirty schema migration from %s", sourceURL)
+	if err := p.retryDirty(ctx, p.schemaMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.retryDirty()")
+	}
+
+	return nil
+}
+
+// RetryDirtyData runs the failed data migration that left the data migrations dirty again.
+func (p *PostgresMigrator) RetryDirtyData(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Retrying dirty data migration from %s", sourceURL)
+	if err := p.retryDirty(ctx, p.dataMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.retryDirty()")
+	}
+
+	return nil
+}
+
+// SchemaStatus reports the current version, dirty flag and pending migrations of the schema migrations in sourceURL
+func (p *PostgresMigrator) SchemaStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error) {
+	status, err := p.status(ctx, p.schemaMigrationsTable, sourceURL)
 	if err != nil {
-		return errors.Wrapf(err, "migrate.New(): fileURL=%s and connectionURL=%s", sourceURL, p.connStr)
+		return nil, errors.Wrap(err, "PostgresMigrator.status()")
+	}
+
+	return status, nil
+}
+
+// DataStatus reports the current version, dirty flag and pending migrations of the data migrations in sourceURL
+func (p *PostgresMigrator) DataStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error) {
+	status, err := p.status(ctx, p.dataMigrationsTable, sourceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresMigrator.status()")
+	}
+
+	return status, nil
+}
+
+// MigrateDropSchema drops all objects in the current schema of the connection
+//
+// This happens in the following order:
+//  1. Drop views
+//  2. Drop materialized views
+//  3. Drop FK constraints
+//  4. Drop indexes
+//  5. Drop tables
+//  6. Drop sequences
+//  7. Drop types
+//  8. Drop domains
+//  9. Drop functions
+//
+// Types and domains are dropped before functions, as their checks and definitions may call functions in the schema.
+// All statements are executed in a single transaction. Objects owned by extensions are left in place.
+// See [PostgresMigrator.WithDropFilter] to drop only some of the tables.
+func (p *PostgresMigrator) MigrateDropSchema(ctx context.Context) error {
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stmts, err := p.dropSchemaStatements(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if len(stmts) == 0 {
+		ccclogger.FromCtx(ctx).Info("No database objects found to drop")
+
+		return nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Begin()")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return errors.Wrapf(err, "pgx.Tx.Exec(): %s", stmt)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "pgx.Tx.Commit()")
+	}
+
+	return nil
+}
+
+// DropSchemaStatements returns the statements MigrateDropSchema runs, in order, without running them
+func (p *PostgresMigrator) DropSchemaStatements(ctx context.Context) ([]string, error) {
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return p.dropSchemaStatements(ctx, db)
+}
+
+func (p *PostgresMigrator) dropSchemaStatements(ctx context.Context, db *pgxpool.Pool) ([]string, error) {
+	if err := p.dropFilter.validate(); err != nil {
+		return nil, err
+	}
+
+	stmts := make([]dropStatement, 0, 10)
+	for _, dropStatements := range []func(context.Context, *pgxpool.Pool) ([]dropStatement, error){
+		p.viewDropStatements,
+		p.materializedViewDropStatements,
+		p.foreignKeyDropStatements,
+		p.indexDropStatements,
+		p.tableDropStatements,
+		p.sequenceDropStatements,
+		p.typeDropStatements,
+		p.domainDropStatements,
+		p.functionDropStatements,
+	} {
+		objectStmts, err := dropStatements(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, objectStmts...)
+	}
+
+	if p.dropFilter.isZero() {
+		return filterDropStatements(stmts, nil), nil
+	}
+
+	objects, err := postgresDropObjects(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	dropped, err := droppedObjects(p.dropFilter, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterDropStatements(stmts, dropped), nil
+}
+
+func (p *PostgresMigrator) migrateUp(ctx context.Context, migrationsTable, sourceURL string) error {
+	if err := p.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.verifyChecksums()")
+	}
+
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "PostgresMigrator.newMigrate()")
 	}
-	m.Log = new(logger)
 
 	if err := m.Up(); err != nil {
+		err = dirtyError(m, migrationsTable, err)
+		m.Close()
+
 		return errors.Wrapf(err, "migrate.Migrate.Up(): %s", sourceURL)
 	}
 
@@ -46,12 +341,463 @@ func (p *PostgresMigrator) MigrateUpSchema(_ context.Context, sourceURL string)
 	return nil
 }
 
-// FIXME(zredinger): implement this method
-func (p *PostgresMigrator) MigrateUpData(_ context.Context, sourceURL string) error {
-	return errors.New("Not implemented")
+func (p *PostgresMigrator) migrateTo(ctx context.Context, migrationsTable, sourceURL string, version uint) error {
+	if err := p.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.verifyChecksums()")
+	}
+
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "PostgresMigrator.newMigrate()")
+	}
+
+	if err := m.Migrate(version); err != nil {
+		err = dirtyError(m, migrationsTable, err)
+		m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Migrate(): version %d: %s", version, sourceURL)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Close(): source error: %s", sourceURL)
+	} else if dbErr != nil {
+		return errors.Wrapf(dbErr, "migrate.Migrate.Close(): database error: %s", sourceURL)
+	}
+
+	return nil
 }
 
-// FIXME(zredinger): implement this method
-func (p *PostgresMigrator) MigrateDropSchema(_ context.Context) error {
-	return errors.New("Not implemented")
+func (p *PostgresMigrator) migrateSteps(ctx context.Context, migrationsTable, sourceURL string, n int) error {
+	if err := p.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.verifyChecksums()")
+	}
+
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "PostgresMigrator.newMigrate()")
+	}
+
+	if err := m.Steps(n); err != nil {
+		err = dirtyError(m, migrationsTable, err)
+		m.Close()
+
+		return errors.Wrapf(err, "migrate.Migrate.Steps(): n=%d: %s", n, sourceURL)
+	}
+
+	if err, dbErr := m.Close(); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Close(): source error: %s", sourceURL)
+	} else if dbErr != nil {
+		return errors.Wrapf(dbErr, "migrate.Migrate.Close(): database error: %s", sourceURL)
+	}
+
+	return nil
+}
+
+func (p *PostgresMigrator) forceVersion(ctx context.Context, migrationsTable, sourceURL string, version int) error {
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "PostgresMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Force(): version %d", version)
+	}
+
+	return nil
+}
+
+func (p *PostgresMigrator) retryDirty(ctx context.Context, migrationsTable, sourceURL string) error {
+	if err := p.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "PostgresMigrator.verifyChecksums()")
+	}
+
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "PostgresMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	src, err := p.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := retryDirty(m, src); err != nil {
+		return dirtyError(m, migrationsTable, err)
+	}
+
+	return nil
+}
+
+func (p *PostgresMigrator) status(ctx context.Context, migrationsTable, sourceURL string) (*MigrationStatus, error) {
+	m, err := p.newMigrate(ctx, migrationsTable, sourceURL, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	src, err := p.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return newMigrationStatus(m, src, migrationsTable, sourceURL)
+}
+
+// newMigrate creates a new migrate instance. Unless readOnly is set, the history and audit tables are created when
+// they are enabled and missing. A read only instance records nothing, so it must not apply migrations.
+func (p *PostgresMigrator) newMigrate(ctx context.Context, migrationsTable, sourceURL string, readOnly bool) (*migrate.Migrate, error) {
+	databaseURL, err := p.databaseURL(migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := p.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := database.Open(databaseURL)
+	if err != nil {
+		_ = src.Close()
+
+		return nil, errors.Wrapf(err, "database.Open(): connectionURL=%s", p.connStr)
+	}
+	if p.hasGoMigrations(migrationsTable) {
+		driver = &goMigrationDriver{Driver: driver, ctx: ctx, run: p.runGoMigration}
+	}
+	if !readOnly && (p.checksumPolicy != 0 || p.auditLog) {
+		hDriver := &historyDriver{Driver: driver, ctx: ctx, src: src, operator: p.operatorName()}
+		if p.checksumPolicy != 0 {
+			if hDriver.history, err = p.newHistory(ctx, migrationsTable); err != nil {
+				_ = driver.Close()
+				_ = src.Close()
+
+				return nil, err
+			}
+		}
+		if p.auditLog {
+			if hDriver.audit, err = p.newAuditLog(ctx, migrationsTable); err != nil {
+				_ = hDriver.Close()
+				_ = src.Close()
+
+				return nil, err
+			}
+		}
+		driver = hDriver
+	}
+
+	m, err := migrate.NewWithInstance(sourceURL, src, "postgres", driver)
+	if err != nil {
+		_ = driver.Close()
+		_ = src.Close()
+
+		return nil, errors.Wrapf(err, "migrate.NewWithInstance(): fileURL=%s and connectionURL=%s", sourceURL, p.connStr)
+	}
+	m.Log = new(logger)
+
+	return m, nil
+}
+
+// newPostgresMigrate creates a migrate instance for the migrations at sourceURL, read from fsys when it is set
+func newPostgresMigrate(fsys fs.FS, sourceURL, databaseURL string) (*migrate.Migrate, error) {
+	src, err := openSource(fsys, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance(sourceURL, src, databaseURL)
+	if err != nil {
+		_ = src.Close()
+
+		return nil, errors.Wrapf(err, "migrate.NewWithSourceInstance(): fileURL=%s and connectionURL=%s", sourceURL, databaseURL)
+	}
+
+	return m, nil
+}
+
+// databaseURL returns the connection string configured to store versions in migrationsTable
+func (p *PostgresMigrator) databaseURL(migrationsTable string) (string, error) {
+	u, err := url.Parse(p.connStr)
+	if err != nil {
+		return "", errors.Wrap(err, "url.Parse()")
+	}
+
+	if migrationsTable != "" {
+		table, err := quoteMigrationsTable(migrationsTable)
+		if err != nil {
+			return "", err
+		}
+
+		q := u.Query()
+		q.Set("x-migrations-table", table)
+		q.Set("x-migrations-table-quoted", "true")
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
+
+// quoteMigrationsTable quotes a table name that is optionally qualified with a schema,
+// e.g. ops.schema_migrations becomes "ops"."schema_migrations"
+func quoteMigrationsTable(table string) (string, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) > 2 || slices.Contains(parts, "") {
+		return "", errors.Newf("invalid migrations table %q: want <table> or <schema>.<table>", table)
+	}
+
+	return pgx.Identifier(parts).Sanitize(), nil
+}
+
+// postgresQueryStrings returns the single text column of every row returned by query
+func postgresQueryStrings(ctx context.Context, db *pgxpool.Pool, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "pgxpool.Pool.Query()")
+	}
+	defer rows.Close()
+
+	var stmts []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, errors.Wrap(err, "pgx.Rows.Scan()")
+		}
+		stmts = append(stmts, stmt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "pgx.Rows.Err()")
+	}
+
+	return stmts, nil
+}
+
+// postgresDropStatements runs a query returning the ddl of each statement and the objects it belongs to
+func postgresDropStatements(ctx context.Context, db *pgxpool.Pool, query string) ([]dropStatement, error) {
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "pgxpool.Pool.Query()")
+	}
+	defer rows.Close()
+
+	var stmts []dropStatement
+	for rows.Next() {
+		var stmt dropStatement
+		if err := rows.Scan(&stmt.SQL, &stmt.Objects); err != nil {
+			return nil, errors.Wrap(err, "pgx.Rows.Scan()")
+		}
+		stmts = append(stmts, stmt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "pgx.Rows.Err()")
+	}
+
+	return stmts, nil
+}
+
+// postgresDropObjects returns the tables and views in the current schema. Tables require the tables they inherit
+// from and views require the tables and views they read from. Partitions are dropped with their parent.
+func postgresDropObjects(ctx context.Context, db *pgxpool.Pool) ([]dropObject, error) {
+	query := `
+		SELECT c.relname, ARRAY(
+			SELECT parent.relname
+			FROM pg_catalog.pg_inherits inh
+			JOIN pg_catalog.pg_class parent ON parent.oid = inh.inhparent
+			WHERE inh.inhrelid = c.oid
+			UNION
+			SELECT ref.relname
+			FROM pg_catalog.pg_rewrite r
+			JOIN pg_catalog.pg_depend d ON d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.objid = r.oid
+			JOIN pg_catalog.pg_class ref ON d.refclassid = 'pg_catalog.pg_class'::regclass AND ref.oid = d.refobjid
+			WHERE r.ev_class = c.oid AND ref.oid <> c.oid
+		)::text[]
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND c.relkind IN ('r', 'p', 'v', 'm')
+			AND NOT c.relispartition
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')`
+
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "pgxpool.Pool.Query()")
+	}
+	defer rows.Close()
+
+	var objects []dropObject
+	for rows.Next() {
+		var o dropObject
+		if err := rows.Scan(&o.Name, &o.Requires); err != nil {
+			return nil, errors.Wrap(err, "pgx.Rows.Scan()")
+		}
+		objects = append(objects, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "pgx.Rows.Err()")
+	}
+
+	return objects, nil
+}
+
+// viewDropStatements uses CASCADE so views built on other views are dropped regardless of order
+func (p *PostgresMigrator) viewDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP VIEW IF EXISTS %I.%I CASCADE', n.nspname, c.relname) AS ddl, ARRAY[c.relname]::text[] AS objects
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND c.relkind = 'v'
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+		ORDER BY c.relname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+func (p *PostgresMigrator) materializedViewDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP MATERIALIZED VIEW IF EXISTS %I.%I CASCADE', n.nspname, c.relname) AS ddl, ARRAY[c.relname]::text[] AS objects
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND c.relkind = 'm'
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+		ORDER BY c.relname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// foreignKeyDropStatements skips constraints inherited by partitions, they are dropped with the parent constraint.
+// Each constraint belongs to both the constrained table and the referenced table.
+func (p *PostgresMigrator) foreignKeyDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('ALTER TABLE %I.%I DROP CONSTRAINT IF EXISTS %I', n.nspname, c.relname, con.conname) AS ddl,
+			ARRAY[c.relname, ref.relname]::text[] AS objects
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_class ref ON ref.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND con.contype = 'f'
+			AND con.conparentid = 0
+		ORDER BY c.relname, con.conname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// indexDropStatements skips indexes backing constraints and partition indexes, they are dropped with their table
+func (p *PostgresMigrator) indexDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP INDEX IF EXISTS %I.%I', n.nspname, c.relname) AS ddl, ARRAY[t.relname]::text[] AS objects
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+		JOIN pg_catalog.pg_class t ON t.oid = i.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND t.relkind IN ('r', 'p', 'm')
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_constraint con WHERE con.conindid = c.oid)
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_inherits inh WHERE inh.inhrelid = c.oid)
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+		ORDER BY t.relname, c.relname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// tableDropStatements orders inheritance children before their parents. Partitions are dropped with their parent.
+func (p *PostgresMigrator) tableDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		WITH RECURSIVE t AS (
+			SELECT c.oid, n.nspname, c.relname, 0 AS depth
+			FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = current_schema()
+				AND c.relkind IN ('r', 'p')
+				AND NOT c.relispartition
+				AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_inherits inh WHERE inh.inhrelid = c.oid)
+				AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+			UNION ALL
+			SELECT c.oid, n.nspname, c.relname, t.depth + 1
+			FROM t
+			JOIN pg_catalog.pg_inherits inh ON inh.inhparent = t.oid
+			JOIN pg_catalog.pg_class c ON c.oid = inh.inhrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE NOT c.relispartition
+		)
+		SELECT format('DROP TABLE IF EXISTS %I.%I', nspname, relname) AS ddl, ARRAY[relname]::text[] AS objects
+		FROM t
+		GROUP BY nspname, relname
+		ORDER BY MAX(depth) DESC, relname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// sequenceDropStatements skips sequences owned by serial and identity columns, they are dropped with their table
+func (p *PostgresMigrator) sequenceDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP SEQUENCE IF EXISTS %I.%I', n.nspname, c.relname) AS ddl, '{}'::text[] AS objects
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+			AND c.relkind = 'S'
+			AND NOT EXISTS(
+				SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.objid = c.oid
+					AND d.classid = 'pg_catalog.pg_class'::regclass
+					AND d.deptype IN ('a', 'i', 'e')
+			)
+		ORDER BY c.relname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// functionDropStatements drops aggregates before the functions they are built on. Functions that belong to a type,
+// such as the constructors of a range type, are dropped with the type.
+func (p *PostgresMigrator) functionDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP %s IF EXISTS %I.%I(%s)',
+			CASE pr.prokind
+				WHEN 'a' THEN 'AGGREGATE'
+				WHEN 'p' THEN 'PROCEDURE'
+				ELSE 'FUNCTION'
+			END,
+			n.nspname, pr.proname, pg_catalog.pg_get_function_identity_arguments(pr.oid)
+		) AS ddl, '{}'::text[] AS objects
+		FROM pg_catalog.pg_proc pr
+		JOIN pg_catalog.pg_namespace n ON n.oid = pr.pronamespace
+		WHERE n.nspname = current_schema()
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = pr.oid AND d.deptype IN ('e', 'i'))
+		ORDER BY pr.prokind = 'a' DESC, pr.proname, pg_catalog.pg_get_function_identity_arguments(pr.oid)`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+// typeDropStatements drops enum, range and standalone composite types.
+// CASCADE covers types and domains that are built on other types.
+func (p *PostgresMigrator) typeDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP TYPE IF EXISTS %I.%I CASCADE', n.nspname, ty.typname) AS ddl, '{}'::text[] AS objects
+		FROM pg_catalog.pg_type ty
+		JOIN pg_catalog.pg_namespace n ON n.oid = ty.typnamespace
+		LEFT JOIN pg_catalog.pg_class c ON c.oid = ty.typrelid
+		WHERE n.nspname = current_schema()
+			AND (ty.typtype IN ('e', 'r') OR (ty.typtype = 'c' AND c.relkind = 'c'))
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = ty.oid AND d.deptype = 'e')
+		ORDER BY ty.typname`
+
+	return postgresDropStatements(ctx, db, query)
+}
+
+func (p *PostgresMigrator) domainDropStatements(ctx context.Context, db *pgxpool.Pool) ([]dropStatement, error) {
+	query := `
+		SELECT format('DROP DOMAIN IF EXISTS %I.%I CASCADE', n.nspname, ty.typname) AS ddl, '{}'::text[] AS objects
+		FROM pg_catalog.pg_type ty
+		JOIN pg_catalog.pg_namespace n ON n.oid = ty.typnamespace
+		WHERE n.nspname = current_schema()
+			AND ty.typtype = 'd'
+			AND NOT EXISTS(SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = ty.oid AND d.deptype = 'e')
+		ORDER BY ty.typname`
+
+	return postgresDropStatements(ctx, db, query)
 }
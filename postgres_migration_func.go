@@ -0,0 +1,86 @@
+package dbinitiator
+
+import (
+	"context"
+
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresMigrationFunc is a data migration written in Go, for changes that cannot be expressed in SQL.
+// The transaction is committed if the function returns nil.
+type PostgresMigrationFunc func(ctx context.Context, tx pgx.Tx) error
+
+type postgresGoMigration struct {
+	identifier string
+	fn         PostgresMigrationFunc
+}
+
+// WithDataMigrationFunc registers fn as the data migration for version.
+//
+// Go migrations run in version order with the files of the data migrations source and are tracked in the
+// data migrations table. They have no down migration. The version must not also be used by a migration file.
+func (p *PostgresMigrator) WithDataMigrationFunc(version uint, identifier string, fn PostgresMigrationFunc) *PostgresMigrator {
+	if p.dataMigrationFuncs == nil {
+		p.dataMigrationFuncs = make(map[uint]postgresGoMigration)
+	}
+	p.dataMigrationFuncs[version] = postgresGoMigration{identifier: identifier, fn: fn}
+
+	return p
+}
+
+// hasGoMigrations reports whether Go migrations are registered for migrationsTable
+func (p *PostgresMigrator) hasGoMigrations(migrationsTable string) bool {
+	return migrationsTable == p.dataMigrationsTable && len(p.dataMigrationFuncs) > 0
+}
+
+// openSource opens the migrations at sourceURL, merging in the Go migrations registered for migrationsTable
+func (p *PostgresMigrator) openSource(migrationsTable, sourceURL string) (source.Driver, error) {
+	src, err := openSource(p.sourceFS, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if !p.hasGoMigrations(migrationsTable) {
+		return src, nil
+	}
+
+	identifiers := make(map[uint]string, len(p.dataMigrationFuncs))
+	for version, m := range p.dataMigrationFuncs {
+		identifiers[version] = m.identifier
+	}
+
+	return newGoMigrationSource(src, identifiers)
+}
+
+func (p *PostgresMigrator) runGoMigration(ctx context.Context, version uint) error {
+	m, ok := p.dataMigrationFuncs[version]
+	if !ok {
+		return errors.Newf("no Go migration registered for version %d", version)
+	}
+
+	ccclogger.FromCtx(ctx).Infof("Running Go data migration %d_%s", version, m.identifier)
+
+	db, err := openDB(ctx, p.connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pgxpool.Pool.Begin()")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := m.fn(ctx, tx); err != nil {
+		return errors.Wrapf(err, "PostgresMigrationFunc(): %d_%s", version, m.identifier)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "pgx.Tx.Commit()")
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package dbinitiator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/errors/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// PostgresTemplate creates databases that are copies of a template database migrated up once,
+// which is much faster than migrating each database. Use [PostgresContainer.Template] to create one.
+type PostgresTemplate struct {
+	container    *PostgresContainer
+	sourceURLs   []string
+	sourceFS     fs.FS
+	resetVersion bool
+}
+
+// postgresTemplateState tracks the preparation of a template database within the process
+type postgresTemplateState struct {
+	mu    sync.Mutex
+	ready bool
+}
+
+// Template returns a PostgresTemplate for the migrations from sourceURL, applied in order as with
+// [PostgresDatabase.MigrateUp].
+func (pc *PostgresContainer) Template(sourceURL ...string) *PostgresTemplate {
+	return &PostgresTemplate{
+		container:  pc,
+		sourceURLs: sourceURL,
+	}
+}
+
+// WithVersionReset clears the migration version before applying each source, as with [PostgresDatabase.WithVersionReset].
+func (t *PostgresTemplate) WithVersionReset() *PostgresTemplate {
+	t.resetVersion = true
+
+	return t
+}
+
+// WithSourceFS reads migrations from fsys, as with [PostgresDatabase.WithSourceFS].
+func (t *PostgresTemplate) WithSourceFS(fsys fs.FS) *PostgresTemplate {
+	t.sourceFS = fsys
+
+	return t
+}
+
+// CreateDatabase creates a database with dbName as a copy of the template database. Each test should create their
+// own database for testing.
+//
+// The template database is named after a hash of the migration contents. It is migrated by the first call and reused
+// by later calls, including concurrent ones and those from other processes sharing the server. Changing a migration
+// prepares a new template database.
+func (t *PostgresTemplate) CreateDatabase(ctx context.Context, dbName string) (*PostgresDatabase, error) {
+	pc := t.container
+
+	hash, err := t.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	templateName, err := pc.prepareTemplate(ctx, hash, t)
+	if err != nil {
+		return nil, err
+	}
+
+	dbName = pc.validDatabaseName(dbName)
+	db, err := pc.superUserConnection(ctx, pc.defaultDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(ctx, fmt.Sprintf(`CREATE DATABASE %q WITH OWNER = %q TEMPLATE = %q;`, dbName, pc.unprivilegedUsername, templateName)); err != nil {
+		return nil, errors.Wrapf(err, "failed to create database=%q from template=%q", dbName, templateName)
+	}
+
+	connStr := PostgresConnStr(pc.unprivilegedUsername, pc.password, pc.host, pc.port.Port(), dbName, pc.sslMode)
+	u, err := openDB(ctx, connStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to database=%q with %s", dbName, pc.unprivilegedUsername)
+	}
+
+	return &PostgresDatabase{
+		Pool:     u,
+		dbName:   dbName,
+		schema:   pc.unprivilegedUsername,
+		connStr:  connStr,
+		sourceFS: t.sourceFS,
+	}, nil
+}
+
+// hash returns the hex encoded SHA-256 of the up migrations of every source, along with the settings that change
+// the template database
+func (t *PostgresTemplate) hash() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "user=%s\nextensions=%s\nlocale=%s\nencoding=%s\nreset=%t\n",
+		t.container.unprivilegedUsername, strings.Join(t.container.extensions, ","), t.container.locale, t.container.encoding, t.resetVersion)
+
+	for _, sourceURL := range t.sourceURLs {
+		fmt.Fprintf(h, "source=%s\n", sourceURL)
+		if err := hashSource(h, t.sourceFS, sourceURL); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// prepareTemplate returns the name of the template database for hash, creating and migrating it when it does not
+// exist yet. Preparation is serialized within the process by the template state and across processes by an advisory
+// lock, and a database left behind by a failed preparation is dropped and prepared again.
+func (pc *PostgresContainer) prepareTemplate(ctx context.Context, hash string, t *PostgresTemplate) (string, error) {
+	templateName := "template_" + hash[:32]
+
+	pc.tMu.Lock()
+	if pc.templates == nil {
+		pc.templates = make(map[string]*postgresTemplateState)
+	}
+	state, ok := pc.templates[hash]
+	if !ok {
+		state = &postgresTemplateState{}
+		pc.templates[hash] = state
+	}
+	pc.tMu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.ready {
+		return templateName, nil
+	}
+
+	pool, err := pc.superUserConnection(ctx, pc.defaultDatabase)
+	if err != nil {
+		return "", err
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "pgxpool.Pool.Acquire()")
+	}
+	defer conn.Release()
+
+	lockID, err := strconv.ParseUint(hash[:16], 16, 64)
+	if err != nil {
+		return "", errors.Wrap(err, "strconv.ParseUint()")
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, int64(lockID)); err != nil {
+		return "", errors.Wrap(err, "failed to lock template preparation")
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(lockID))
+	}()
+
+	var isTemplate bool
+	err = conn.QueryRow(ctx, `SELECT datistemplate FROM pg_database WHERE datname = $1`, templateName).Scan(&isTemplate)
+	switch {
+	case err == nil && isTemplate:
+		state.ready = true
+
+		return templateName, nil
+	case err == nil:
+		if err := pc.dropDatabase(ctx, templateName); err != nil {
+			return "", err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return "", errors.Wrapf(err, "failed to look up template database=%q", templateName)
+	}
+
+	if err := pc.migrateTemplate(ctx, templateName, t); err != nil {
+		return "", err
+	}
+
+	// no connections are allowed, as a database can only be copied while nobody is connected to it
+	if _, err := conn.Exec(ctx, fmt.Sprintf(`ALTER DATABASE %q WITH IS_TEMPLATE = true ALLOW_CONNECTIONS = false;`, templateName)); err != nil {
+		return "", errors.Wrapf(err, "failed to mark database=%q as a template", templateName)
+	}
+	state.ready = true
+
+	return templateName, nil
+}
+
+// migrateTemplate creates the database templateName and applies the migrations of t to it
+func (pc *PostgresContainer) migrateTemplate(ctx context.Context, templateName string, t *PostgresTemplate) error {
+	db, err := pc.CreateDatabase(ctx, templateName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	db.WithSourceFS(t.sourceFS)
+	if t.resetVersion {
+		db.WithVersionReset()
+	}
+
+	if err := db.MigrateUp(t.sourceURLs...); err != nil {
+		return errors.Wrapf(err, "failed to migrate template database=%q", templateName)
+	}
+
+	return nil
+}
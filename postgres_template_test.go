@@ -0,0 +1,119 @@
+package dbinitiator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPostgresTemplate_hash(t *testing.T) {
+	t.Parallel()
+
+	base := fstest.MapFS{
+		"migrations/1_init.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/1_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"migrations/2_more.up.sql":    {Data: []byte("CREATE TABLE b (id INT);")},
+		"migrations/2_more.down.sql":  {Data: []byte("DROP TABLE b;")},
+		"migrations/3_noop.down.sql":  {Data: []byte("")},
+		"migrations/notamigration.md": {Data: []byte("ignored")},
+	}
+	changed := fstest.MapFS{}
+	for k, v := range base {
+		changed[k] = v
+	}
+	changed["migrations/2_more.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id BIGINT);")}
+	downOnly := fstest.MapFS{}
+	for k, v := range base {
+		downOnly[k] = v
+	}
+	downOnly["migrations/2_more.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE IF EXISTS b;")}
+
+	container := &PostgresContainer{unprivilegedUsername: "unprivileged", extensions: []string{"btree_gist"}}
+	hash := func(container *PostgresContainer, fsys fstest.MapFS, reset bool) string {
+		t.Helper()
+
+		tmpl := container.Template("migrations").WithSourceFS(fsys)
+		if reset {
+			tmpl.WithVersionReset()
+		}
+		h, err := tmpl.hash()
+		if err != nil {
+			t.Fatalf("PostgresTemplate.hash() error = %v", err)
+		}
+
+		return h
+	}
+
+	localized := &PostgresContainer{unprivilegedUsername: "unprivileged", extensions: []string{"btree_gist"}, locale: "C"}
+	encoded := &PostgresContainer{unprivilegedUsername: "unprivileged", extensions: []string{"btree_gist"}, encoding: "LATIN1"}
+
+	want := hash(container, base, false)
+	tests := []struct {
+		name      string
+		container *PostgresContainer
+		fsys      fstest.MapFS
+		reset     bool
+		wantSame  bool
+	}{
+		{name: "Same contents", container: container, fsys: base, wantSame: true},
+		{name: "Down migration changed", container: container, fsys: downOnly, wantSame: true},
+		{name: "Up migration changed", container: container, fsys: changed},
+		{name: "Version reset", container: container, fsys: base, reset: true},
+		{name: "Locale", container: localized, fsys: base},
+		{name: "Encoding", container: encoded, fsys: base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := hash(tt.container, tt.fsys, tt.reset); (got == want) != tt.wantSame {
+				t.Errorf("PostgresTemplate.hash() = %s, base = %s, wantSame %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestPostgresTemplate_CreateDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewPostgresContainer(ctx, "16")
+	if err != nil {
+		t.Fatalf("NewPostgresContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	tmpl := container.Template("file://testdata/postgres/migrations_full")
+
+	var wg sync.WaitGroup
+	dbs := make([]*PostgresDatabase, 4)
+	errs := make([]error, len(dbs))
+	for i := range dbs {
+		wg.Go(func() {
+			dbs[i], errs[i] = tmpl.CreateDatabase(ctx, genDBName())
+		})
+	}
+	wg.Wait()
+
+	for i, db := range dbs {
+		if errs[i] != nil {
+			t.Fatalf("PostgresTemplate.CreateDatabase() error = %v", errs[i])
+		}
+		defer db.Close()
+
+		if ok, err := pgAssertionQuery(ctx, db.Pool, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = 1 AND NOT dirty)`); err != nil || !ok {
+			t.Errorf("database %d should be migrated, got %v, err=%v", i, ok, err)
+		}
+		if _, err := db.Exec(ctx, `INSERT INTO products (name, price) VALUES ('widget', 9.99)`); err != nil {
+			t.Errorf("database %d insert error = %v", i, err)
+		}
+	}
+
+	if ok, err := pgAssertionQuery(ctx, dbs[0].Pool, `SELECT (SELECT COUNT(*) FROM products) = 1`); err != nil || !ok {
+		t.Errorf("databases should not share data, got %v, err=%v", ok, err)
+	}
+	if ok, err := pgAssertionQuery(ctx, dbs[0].Pool, `SELECT COUNT(*) = 1 FROM pg_database WHERE datistemplate AND datname LIKE 'template\_%'`); err != nil || !ok {
+		t.Errorf("template database should be prepared once, got %v, err=%v", ok, err)
+	}
+}
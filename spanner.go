@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"sync"
+	"time"
 
 	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"github.com/go-playground/errors/v5"
@@ -15,7 +18,9 @@ import (
 	"google.golang.org/api/option"
 	"google.golang.org/api/option/internaloption"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -24,7 +29,8 @@ const (
 	defaultSpannerInstanceID = "test-instance"
 )
 
-// SpannerContainer represents a docker container running a spanner instance.
+// SpannerContainer represents a docker container running a spanner instance,
+// or an emulator attached with [ConnectSpannerEmulator].
 // [SpannerContainer.Close] should be called to cleanup resources.
 type SpannerContainer struct {
 	testcontainers.Container
@@ -36,21 +42,100 @@ type SpannerContainer struct {
 
 	mu      sync.Mutex
 	dbCount int
+
+	tMu       sync.Mutex
+	templates map[string]*spannerTemplateState
+}
+
+type spannerContainerConfig struct {
+	projectID      string
+	instanceID     string
+	instanceConfig string
+	image          string
+	startupTimeout time.Duration
+	customizers    []testcontainers.ContainerCustomizer
+}
+
+// SpannerContainerOption configures the container started by [NewSpannerContainer]
+type SpannerContainerOption func(*spannerContainerConfig)
+
+// WithSpannerProjectID sets the project the instance is created in. The default is "unit-testing".
+func WithSpannerProjectID(projectID string) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.projectID = projectID
+	}
+}
+
+// WithSpannerInstanceID sets the ID of the instance databases are created in. The default is "test-instance".
+func WithSpannerInstanceID(instanceID string) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.instanceID = instanceID
+	}
+}
+
+// WithSpannerInstanceConfig sets the instance configuration, e.g. "emulator-config".
+// The emulator picks its own default when it is not set.
+func WithSpannerInstanceConfig(instanceConfig string) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.instanceConfig = instanceConfig
+	}
+}
+
+// WithSpannerImage sets the full image reference of the emulator, e.g. to pull it from a private mirror.
+// The imageVersion passed to [NewSpannerContainer] is then ignored.
+func WithSpannerImage(image string) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.image = image
+	}
+}
+
+// WithSpannerStartupTimeout sets how long to wait for the emulator to report it is running
+func WithSpannerStartupTimeout(timeout time.Duration) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.startupTimeout = timeout
+	}
+}
+
+// WithSpannerContainerCustomizers applies customizers to the container request, e.g. [testcontainers.WithEnv] or
+// [testcontainers.WithHostConfigModifier], after the defaults are set
+func WithSpannerContainerCustomizers(customizers ...testcontainers.ContainerCustomizer) SpannerContainerOption {
+	return func(c *spannerContainerConfig) {
+		c.customizers = append(c.customizers, customizers...)
+	}
 }
 
 // NewSpannerContainer returns a initialized [SpannerContainer] ready to run to create databases for unit tests.
 // [SpannerContainer.Close] should be called to cleanup resources.
-func NewSpannerContainer(ctx context.Context, imageVersion string) (*SpannerContainer, error) {
-	req := testcontainers.ContainerRequest{
-		Image:        "gcr.io/cloud-spanner-emulator/emulator:" + imageVersion,
-		WaitingFor:   wait.ForLog("Cloud Spanner emulator running"),
-		ExposedPorts: []string{defaultSpannerPort},
+func NewSpannerContainer(ctx context.Context, imageVersion string, options ...SpannerContainerOption) (*SpannerContainer, error) {
+	conf := &spannerContainerConfig{
+		projectID:  defaultSpannerProjectID,
+		instanceID: defaultSpannerInstanceID,
+		image:      "gcr.io/cloud-spanner-emulator/emulator:" + imageVersion,
+	}
+	for _, o := range options {
+		o(conf)
 	}
 
-	spannerC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-		Started:          true,
-		ContainerRequest: req,
-	})
+	waitFor := wait.ForLog("Cloud Spanner emulator running")
+	if conf.startupTimeout > 0 {
+		waitFor = waitFor.WithStartupTimeout(conf.startupTimeout)
+	}
+
+	genericReq := testcontainers.GenericContainerRequest{
+		Started: true,
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        conf.image,
+			WaitingFor:   waitFor,
+			ExposedPorts: []string{defaultSpannerPort},
+		},
+	}
+	for _, c := range conf.customizers {
+		if err := c.Customize(&genericReq); err != nil {
+			return nil, errors.Wrap(err, "testcontainers.ContainerCustomizer.Customize()")
+		}
+	}
+
+	spannerC, err := testcontainers.GenericContainer(ctx, genericReq)
 	if err != nil {
 		return nil, errors.Wrap(err, "testcontainers.GenericContainer()")
 	}
@@ -65,16 +150,9 @@ func NewSpannerContainer(ctx context.Context, imageVersion string) (*SpannerCont
 		return nil, errors.Wrapf(err, "failed to get external port for exposed port %s", defaultSpannerPort)
 	}
 
-	endPoint := fmt.Sprintf("passthrough:///%s:%s", host, externalPort.Port())
+	opts := emulatorClientOptions(fmt.Sprintf("%s:%s", host, externalPort.Port()))
 
-	opts := []option.ClientOption{
-		option.WithEndpoint(endPoint),
-		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
-		option.WithoutAuthentication(),
-		internaloption.SkipDialSettingsValidation(),
-	}
-
-	if err := NewSpannerInstance(ctx, defaultSpannerProjectID, defaultSpannerInstanceID, opts...); err != nil {
+	if err := newSpannerInstance(ctx, conf.projectID, conf.instanceID, conf.instanceConfig, opts...); err != nil {
 		return nil, errors.Wrap(err, "failed to create spanner instance")
 	}
 
@@ -88,16 +166,81 @@ func NewSpannerContainer(ctx context.Context, imageVersion string) (*SpannerCont
 		admin:      admin,
 		opts:       opts,
 		port:       defaultSpannerPort,
+		projectID:  conf.projectID,
+		instanceID: conf.instanceID,
+	}, nil
+}
+
+// ConnectSpannerEmulator returns a SpannerContainer for an emulator that is already running, e.g. a CI job service,
+// without starting a container. hostPort is the gRPC address of the emulator, and SPANNER_EMULATOR_HOST is used when
+// it is empty. The instance is created unless it already exists. Only the project, instance and instance config
+// options apply, and [SpannerContainer.Terminate] does nothing.
+// [SpannerContainer.Close] should be called to cleanup resources.
+func ConnectSpannerEmulator(ctx context.Context, hostPort string, options ...SpannerContainerOption) (*SpannerContainer, error) {
+	if hostPort == "" {
+		hostPort = os.Getenv("SPANNER_EMULATOR_HOST")
+	}
+	if hostPort == "" {
+		return nil, errors.New("no emulator address: hostPort is empty and SPANNER_EMULATOR_HOST is not set")
+	}
+
+	conf := &spannerContainerConfig{
 		projectID:  defaultSpannerProjectID,
 		instanceID: defaultSpannerInstanceID,
+	}
+	for _, o := range options {
+		o(conf)
+	}
+
+	opts := emulatorClientOptions(hostPort)
+
+	if err := newSpannerInstance(ctx, conf.projectID, conf.instanceID, conf.instanceConfig, opts...); err != nil && status.Code(err) != codes.AlreadyExists {
+		return nil, errors.Wrap(err, "failed to create spanner instance")
+	}
+
+	admin, err := database.NewDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "database.NewDatabaseAdminClient()")
+	}
+
+	return &SpannerContainer{
+		admin:      admin,
+		opts:       opts,
+		projectID:  conf.projectID,
+		instanceID: conf.instanceID,
 	}, nil
 }
 
+// emulatorClientOptions returns the options that connect a client to the emulator at hostPort without credentials
+func emulatorClientOptions(hostPort string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint("passthrough:///" + hostPort),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithoutAuthentication(),
+		internaloption.SkipDialSettingsValidation(),
+	}
+}
+
+// ProjectID returns the project the instance was created in
+func (sc *SpannerContainer) ProjectID() string {
+	return sc.projectID
+}
+
+// InstanceID returns the ID of the instance databases are created in
+func (sc *SpannerContainer) InstanceID() string {
+	return sc.instanceID
+}
+
+// ClientOptions returns the options that connect a client to the emulator, e.g. for [NewSpannerMigrator]
+func (sc *SpannerContainer) ClientOptions() []option.ClientOption {
+	return slices.Clone(sc.opts)
+}
+
 // CreateDatabase creates a database with dbName. Each test should create their own database for testing
 func (sc *SpannerContainer) CreateDatabase(ctx context.Context, dbName string) (*SpannerDB, error) {
 	dbName = sc.validDatabaseName(dbName)
 
-	db, err := newSpannerDatabase(ctx, sc.admin, sc.projectID, sc.instanceID, dbName, sc.opts...)
+	db, err := newSpannerDatabase(ctx, sc.admin, sc.projectID, sc.instanceID, dbName, nil, sc.opts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create spanner database %s", dbName)
 	}
@@ -105,6 +248,19 @@ func (sc *SpannerContainer) CreateDatabase(ctx context.Context, dbName string) (
 	return db, nil
 }
 
+// Terminate stops and removes the container. It does nothing for an emulator attached with [ConnectSpannerEmulator].
+func (sc *SpannerContainer) Terminate(ctx context.Context, opts ...testcontainers.TerminateOption) error {
+	if sc == nil || sc.Container == nil {
+		return nil
+	}
+
+	if err := sc.Container.Terminate(ctx, opts...); err != nil {
+		return errors.Wrap(err, "testcontainers.Container.Terminate()")
+	}
+
+	return nil
+}
+
 // Close cleans up open resources
 func (sc *SpannerContainer) Close() error {
 	if err := sc.admin.Close(); err != nil {
@@ -0,0 +1,206 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/iterator"
+)
+
+// WithAuditLog writes a [MigrationRecord] each time a migration is run to an audit table named after the
+// migrations table with an "Audit" suffix, e.g. "SchemaMigrationsAudit".
+// Read it back with [SpannerMigrator.SchemaAuditLog] and [SpannerMigrator.DataAuditLog].
+func (s *SpannerMigrator) WithAuditLog() *SpannerMigrator {
+	s.auditLog = true
+
+	return s
+}
+
+// WithOperator sets the operator recorded in the audit log. It defaults to <user>@<host> of the current process.
+func (s *SpannerMigrator) WithOperator(operator string) *SpannerMigrator {
+	s.operator = operator
+
+	return s
+}
+
+// SchemaAuditLog returns the audit log of the schema migrations, in the order they were run
+func (s *SpannerMigrator) SchemaAuditLog(ctx context.Context) ([]MigrationRecord, error) {
+	records, err := s.auditRecords(ctx, s.schemaMigrationsTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.auditRecords()")
+	}
+
+	return records, nil
+}
+
+// DataAuditLog returns the audit log of the data migrations, in the order they were run
+func (s *SpannerMigrator) DataAuditLog(ctx context.Context) ([]MigrationRecord, error) {
+	records, err := s.auditRecords(ctx, s.dataMigrationsTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.auditRecords()")
+	}
+
+	return records, nil
+}
+
+// auditRecords reads the audit log of migrationsTable. It is empty when the audit table was never created.
+func (s *SpannerMigrator) auditRecords(ctx context.Context, migrationsTable string) ([]MigrationRecord, error) {
+	audit := &spannerAuditLog{client: s.client, table: migrationsTable + "Audit"}
+
+	if exists, err := spannerTableExists(ctx, s.client, audit.table, "Version"); err != nil || !exists {
+		return nil, err
+	}
+
+	return audit.records(ctx)
+}
+
+// auditBatch appends the migrations of a DDL batch that started at started to the audit log.
+// If batchErr is set, the migrations after the one it failed in were not run.
+func (s *SpannerMigrator) auditBatch(ctx context.Context, migrations []PlannedMigration, started time.Time, batchErr *BatchDDLError) error {
+	if !s.auditLog {
+		return nil
+	}
+
+	audit, err := s.newAuditLog(ctx, s.schemaMigrationsTable)
+	if err != nil {
+		return err
+	}
+
+	finished := time.Now()
+	for _, m := range migrations {
+		rec := &MigrationRecord{
+			Version:    m.Version,
+			Identifier: m.Identifier,
+			Direction:  MigrationUp,
+			StartedAt:  started,
+			FinishedAt: finished,
+			Duration:   finished.Sub(started),
+			Operator:   s.operatorName(),
+			Outcome:    MigrationSucceeded,
+		}
+		if batchErr != nil && m.Version == batchErr.Version {
+			rec.Outcome = MigrationFailed
+			rec.Error = batchErr.Error()
+		}
+		if err := audit.append(ctx, rec); err != nil {
+			return errors.Wrapf(err, "migrationAuditLog.append(): version %d", m.Version)
+		}
+		if rec.Outcome == MigrationFailed {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *SpannerMigrator) operatorName() string {
+	if s.operator != "" {
+		return s.operator
+	}
+
+	return defaultOperator()
+}
+
+// spannerAuditLog stores the audit log of a track in a Spanner table
+type spannerAuditLog struct {
+	client *spanner.Client
+	table  string
+}
+
+var _ migrationAuditLog = (*spannerAuditLog)(nil)
+
+// newAuditLog returns the audit log of migrationsTable, creating its table if it does not exist
+func (s *SpannerMigrator) newAuditLog(ctx context.Context, migrationsTable string) (*spannerAuditLog, error) {
+	a := &spannerAuditLog{client: s.client, table: migrationsTable + "Audit"}
+
+	if exists, err := spannerTableExists(ctx, s.client, a.table, "Version"); err != nil {
+		return nil, err
+	} else if exists {
+		return a, nil
+	}
+
+	stmt := fmt.Sprintf(`CREATE TABLE `+"`%s`"+` (
+		StartedAt TIMESTAMP NOT NULL,
+		Version INT64 NOT NULL,
+		Direction STRING(8) NOT NULL,
+		Identifier STRING(MAX) NOT NULL,
+		FinishedAt TIMESTAMP NOT NULL,
+		DurationNs INT64 NOT NULL,
+		Operator STRING(MAX) NOT NULL,
+		Outcome STRING(16) NOT NULL,
+		Error STRING(MAX),
+	) PRIMARY KEY (StartedAt, Version, Direction)`, a.table)
+
+	op, err := s.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   s.connectionString,
+		Statements: []string{stmt},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "SpannerMigrator.admin.UpdateDatabaseDdl(): %s", a.table)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return nil, errors.Wrapf(err, "SpannerMigrator.admin.UpdateDatabaseDdl().Wait(): %s", a.table)
+	}
+
+	return a, nil
+}
+
+func (a *spannerAuditLog) append(ctx context.Context, rec *MigrationRecord) error {
+	var recErr spanner.NullString
+	if rec.Error != "" {
+		recErr = spanner.NullString{StringVal: rec.Error, Valid: true}
+	}
+
+	m := spanner.Insert(a.table,
+		[]string{"StartedAt", "Version", "Direction", "Identifier", "FinishedAt", "DurationNs", "Operator", "Outcome", "Error"},
+		[]any{rec.StartedAt, int64(rec.Version), string(rec.Direction), rec.Identifier, rec.FinishedAt, rec.Duration.Nanoseconds(), rec.Operator, string(rec.Outcome), recErr},
+	)
+	if _, err := a.client.Apply(ctx, []*spanner.Mutation{m}); err != nil {
+		return errors.Wrap(err, "spanner.Client.Apply()")
+	}
+
+	return nil
+}
+
+func (a *spannerAuditLog) records(ctx context.Context) ([]MigrationRecord, error) {
+	stmt := spanner.NewStatement(fmt.Sprintf(`
+		SELECT Version, Identifier, Direction, StartedAt, FinishedAt, DurationNs, Operator, Outcome, Error
+		FROM %s
+		ORDER BY StartedAt, Version`, a.table))
+
+	iter := a.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var records []MigrationRecord
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
+		}
+
+		var version, durationNs int64
+		var direction, outcome string
+		var recErr spanner.NullString
+		var rec MigrationRecord
+		if err := row.Columns(&version, &rec.Identifier, &direction, &rec.StartedAt, &rec.FinishedAt, &durationNs, &rec.Operator, &outcome, &recErr); err != nil {
+			return nil, errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		rec.Version = uint(version)
+		rec.Direction = MigrationDirection(direction)
+		rec.Duration = time.Duration(durationNs)
+		rec.Outcome = MigrationOutcome(outcome)
+		rec.Error = recErr.StringVal
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+func (a *spannerAuditLog) close() {}
@@ -0,0 +1,153 @@
+package dbinitiator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+const (
+	baselineIdentifier = "baseline"
+	baselineUpFile     = "000001_" + baselineIdentifier + ".up.sql"
+	baselineDownFile   = "000001_" + baselineIdentifier + ".down.sql"
+)
+
+// ExportBaseline onboards an existing database by writing its current schema to dir as migration 000001.
+//
+// The up file holds the DDL from GetDatabaseDdl, which Spanner returns in an order the schema can be created in.
+// The down file drops the same objects in the order MigrateDropSchema uses. Tables used to track migrations are
+// left out of both. Version 1 is then recorded in the schema migrations table without running the DDL, along with
+// the checksum of the up file when a checksum policy is set.
+//
+// The schema migrations table must not have a version yet, and existing files in dir are not overwritten.
+func (s *SpannerMigrator) ExportBaseline(ctx context.Context, dir string) error {
+	ccclogger.FromCtx(ctx).Infof("Exporting baseline schema to %s", dir)
+
+	driver, err := s.newDriver(s.schemaMigrationsTable)
+	if err != nil {
+		return err
+	}
+	defer driver.Close()
+
+	if version, _, err := driver.Version(); err != nil {
+		return errors.Wrap(err, "database.Driver.Version()")
+	} else if version != database.NilVersion {
+		return errors.Newf("%s already has version %d: a baseline can only be exported before any migration is applied", s.schemaMigrationsTable, version)
+	}
+
+	upStmts, err := s.databaseDDL(ctx)
+	if err != nil {
+		return err
+	}
+	if len(upStmts) == 0 {
+		return errors.New("database has no schema to export")
+	}
+
+	stmts, err := s.dropStatements(ctx)
+	if err != nil {
+		return err
+	}
+	tracking := s.trackingTables()
+	downStmts := slices.DeleteFunc(filterDropStatements(stmts, nil), func(stmt string) bool {
+		return slices.Contains(tracking, dropTableName(stmt))
+	})
+
+	upPath, downPath := filepath.Join(dir, baselineUpFile), filepath.Join(dir, baselineDownFile)
+	up := migrationFileContents(upStmts)
+	if err := writeMigrationFile(upPath, up); err != nil {
+		return err
+	}
+	// the files are removed on failure so the export can be retried, as existing files are not overwritten
+	if err := writeMigrationFile(downPath, migrationFileContents(downStmts)); err != nil {
+		_ = os.Remove(upPath)
+
+		return err
+	}
+
+	if err := s.recordBaselineChecksum(ctx, up); err != nil {
+		_ = os.Remove(upPath)
+		_ = os.Remove(downPath)
+
+		return err
+	}
+
+	if err := driver.SetVersion(1, false); err != nil {
+		_ = os.Remove(upPath)
+		_ = os.Remove(downPath)
+
+		return errors.Wrap(err, "database.Driver.SetVersion(): version 1")
+	}
+
+	return nil
+}
+
+// recordBaselineChecksum records the checksum of the baseline up file when a checksum policy is set, so the
+// policy does not treat the baseline as changed
+func (s *SpannerMigrator) recordBaselineChecksum(ctx context.Context, up string) error {
+	if s.checksumPolicy == 0 {
+		return nil
+	}
+
+	history, err := s.newHistory(ctx, s.schemaMigrationsTable)
+	if err != nil {
+		return err
+	}
+
+	if err := history.record(ctx, 1, baselineIdentifier, migrationChecksum([]byte(up))); err != nil {
+		return errors.Wrap(err, "migrationHistory.record(): version 1")
+	}
+
+	return nil
+}
+
+// migrationFileContents returns stmts as the contents of a migration file, one statement per paragraph
+func migrationFileContents(stmts []string) string {
+	var b strings.Builder
+	for i, stmt := range stmts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.TrimSpace(stmt))
+		b.WriteString(";\n")
+	}
+
+	return b.String()
+}
+
+// writeMigrationFile writes contents to a new file at path
+func writeMigrationFile(path, contents string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "os.OpenFile(): %s", path)
+	}
+
+	if _, err := f.WriteString(contents); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+
+		return errors.Wrapf(err, "os.File.WriteString(): %s", path)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+
+		return errors.Wrapf(err, "os.File.Close(): %s", path)
+	}
+
+	return nil
+}
+
+// dropTableName returns the table a DROP TABLE statement drops, or an empty string for any other statement
+func dropTableName(stmt string) string {
+	name, ok := strings.CutPrefix(stmt, "DROP TABLE ")
+	if !ok {
+		return ""
+	}
+
+	return strings.Trim(name, "`")
+}
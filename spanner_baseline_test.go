@@ -0,0 +1,154 @@
+package dbinitiator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+func Test_dropTableName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{name: "quoted table", stmt: "DROP TABLE `Accounts`", want: "Accounts"},
+		{name: "unquoted table", stmt: "DROP TABLE SchemaMigrations", want: "SchemaMigrations"},
+		{name: "index", stmt: "DROP INDEX `Accounts_Email`", want: ""},
+		{name: "foreign key", stmt: "ALTER TABLE `Orders` DROP CONSTRAINT `FK_Orders_Accounts`", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := dropTableName(tt.stmt); got != tt.want {
+				t.Errorf("dropTableName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerMigrator_ExportBaseline(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+	svc.WithChecksumPolicy(ChecksumFail)
+
+	op, err := svc.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database: svc.connectionString,
+		Statements: []string{
+			"CREATE TABLE Accounts (Id STRING(36) NOT NULL, Email STRING(MAX)) PRIMARY KEY(Id)",
+			"CREATE INDEX Accounts_Email ON Accounts(Email)",
+			"CREATE TABLE Orders (Id STRING(36) NOT NULL, AccountId STRING(36) NOT NULL, CONSTRAINT FK_Orders_Accounts FOREIGN KEY (AccountId) REFERENCES Accounts(Id)) PRIMARY KEY(Id)",
+			"CREATE VIEW AccountOrders SQL SECURITY INVOKER AS SELECT o.Id, a.Email FROM Orders o JOIN Accounts a ON o.AccountId = a.Id",
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateDatabaseDdl() error = %v", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		t.Fatalf("UpdateDatabaseDdl().Wait() error = %v", err)
+	}
+
+	conflictDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(conflictDir, "000001_baseline.down.sql"), nil, 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	if err := svc.ExportBaseline(ctx, conflictDir); err == nil {
+		t.Errorf("SpannerMigrator.ExportBaseline() error = nil, want error when the down file exists")
+	}
+	if _, err := os.Stat(filepath.Join(conflictDir, "000001_baseline.up.sql")); !os.IsNotExist(err) {
+		t.Errorf("os.Stat() error = %v, want the up file removed after a failed export", err)
+	}
+
+	dir := t.TempDir()
+	sourceURL := "file://" + dir
+	if err := svc.ExportBaseline(ctx, dir); err != nil {
+		t.Fatalf("SpannerMigrator.ExportBaseline() error = %v", err)
+	}
+
+	up, err := os.ReadFile(filepath.Join(dir, "000001_baseline.up.sql"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(up), "CREATE TABLE Accounts") || strings.Contains(string(up), "SchemaMigrations") {
+		t.Errorf("baseline up file = %s, want the schema without SchemaMigrations", up)
+	}
+	down, err := os.ReadFile(filepath.Join(dir, "000001_baseline.down.sql"))
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(down), "DROP VIEW") || strings.Contains(string(down), "SchemaMigrations") {
+		t.Errorf("baseline down file = %s, want the views dropped first and SchemaMigrations kept", down)
+	}
+
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT EXISTS(SELECT 1 FROM SchemaMigrationsHistory WHERE Version = 1 AND Identifier = 'baseline')`); err != nil || !ok {
+		t.Errorf("baseline checksum should be recorded, got %v, err=%v", ok, err)
+	}
+
+	status, err := svc.SchemaStatus(ctx, sourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+	}
+	if status.Version != 1 || status.Dirty || len(status.Pending) != 0 {
+		t.Errorf("SpannerMigrator.SchemaStatus() = %s, want version 1 with nothing pending", status)
+	}
+
+	drift, err := svc.SchemaDrift(ctx, container, sourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaDrift() error = %v", err)
+	}
+	if drift.HasDrift() {
+		t.Errorf("SpannerMigrator.SchemaDrift() = %s, want no drift", drift)
+	}
+
+	if err := svc.ExportBaseline(ctx, t.TempDir()); err == nil {
+		t.Errorf("SpannerMigrator.ExportBaseline() error = nil, want error once a version is recorded")
+	}
+
+	if err := svc.MigrateSchemaSteps(ctx, sourceURL, -1); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateSchemaSteps() error = %v", err)
+	}
+	ddl, err := svc.databaseDDL(ctx)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.databaseDDL() error = %v", err)
+	}
+	if len(ddl) != 0 {
+		t.Errorf("SpannerMigrator.databaseDDL() = %v after the baseline down migration, want none", ddl)
+	}
+}
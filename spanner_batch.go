@@ -0,0 +1,160 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// BatchDDLError is returned when a statement of a batched schema migration fails
+type BatchDDLError struct {
+	// Version and Identifier are the migration file the failed statement is from
+	Version    uint
+	Identifier string
+
+	// Statement is the statement that failed
+	Statement string
+
+	Err error
+}
+
+func (e *BatchDDLError) Error() string {
+	return fmt.Sprintf("batched DDL failed in migration %d_%s at statement %q: %s", e.Version, e.Identifier, strings.TrimSpace(e.Statement), e.Err)
+}
+
+func (e *BatchDDLError) Unwrap() error {
+	return e.Err
+}
+
+// WithBatchedSchemaMigrations makes MigrateUpSchema submit the DDL of all pending migration files as a single
+// UpdateDatabaseDdl batch and record only the final version, which is much faster than one operation per file.
+//
+// Pending schema migrations must only contain DDL. If a statement fails, a *BatchDDLError names it and the
+// schema migrations are left dirty at the version of the migration it is from. Statements before it remain applied.
+func (s *SpannerMigrator) WithBatchedSchemaMigrations() *SpannerMigrator {
+	s.batchSchemaMigrations = true
+
+	return s
+}
+
+// migrateUpBatch applies the pending schema migrations from sourceURL as a single DDL batch. The driver lock is held
+// from planning until the version is recorded, as it is by migrate for the migrations applied one at a time.
+func (s *SpannerMigrator) migrateUpBatch(ctx context.Context, sourceURL string) error {
+	if err := s.verifyChecksums(ctx, s.schemaMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.verifyChecksums()")
+	}
+
+	driver, err := s.newDriver(s.schemaMigrationsTable)
+	if err != nil {
+		return err
+	}
+	defer driver.Close()
+
+	if err := driver.Lock(); err != nil {
+		return errors.Wrap(err, "database.Driver.Lock()")
+	}
+	defer func() {
+		_ = driver.Unlock()
+	}()
+
+	plan, err := s.planUp(ctx, s.schemaMigrationsTable, sourceURL)
+	if err != nil {
+		return errors.Wrap(err, "SpannerMigrator.planUp()")
+	}
+	if len(plan.Migrations) == 0 {
+		ccclogger.FromCtx(ctx).Info("No schema migrations to apply")
+
+		return nil
+	}
+
+	stmts, owners, err := batchStatements(plan.Migrations)
+	if err != nil {
+		return err
+	}
+
+	final := plan.Migrations[len(plan.Migrations)-1]
+	if err := driver.SetVersion(int(final.Version), true); err != nil {
+		return errors.Wrapf(err, "database.Driver.SetVersion(): version %d", final.Version)
+	}
+
+	started := time.Now()
+	if len(stmts) > 0 {
+		ccclogger.FromCtx(ctx).Infof("Applying %d DDL statement(s) from %d schema migration(s) as one batch", len(stmts), len(plan.Migrations))
+
+		op, err := s.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+			Database:   s.connectionString,
+			Statements: stmts,
+		})
+		if err != nil {
+			// the batch was rejected before any statement was applied
+			prev := database.NilVersion
+			if plan.HasVersion {
+				prev = int(plan.Version)
+			}
+			if err := driver.SetVersion(prev, false); err != nil {
+				return errors.Wrapf(err, "database.Driver.SetVersion(): version %d", prev)
+			}
+
+			return errors.Wrap(err, "SpannerMigrator.admin.UpdateDatabaseDdl()")
+		}
+
+		if err := op.Wait(ctx); err != nil {
+			var committed int
+			if md, mdErr := op.Metadata(); mdErr == nil {
+				committed = len(md.GetCommitTimestamps())
+			}
+			failed := min(committed, len(stmts)-1)
+
+			batchErr := &BatchDDLError{Version: owners[failed].Version, Identifier: owners[failed].Identifier, Statement: stmts[failed], Err: err}
+			if err := driver.SetVersion(int(batchErr.Version), true); err != nil {
+				return errors.Join(batchErr, errors.Wrapf(err, "database.Driver.SetVersion(): version %d", batchErr.Version))
+			}
+
+			applied := slices.IndexFunc(plan.Migrations, func(m PlannedMigration) bool { return m.Version == batchErr.Version })
+			if err := s.recordChecksums(ctx, s.schemaMigrationsTable, sourceURL, plan.Migrations[:applied]); err != nil {
+				return errors.Join(batchErr, errors.Wrap(err, "SpannerMigrator.recordChecksums()"))
+			}
+			if err := s.auditBatch(ctx, plan.Migrations, started, batchErr); err != nil {
+				return errors.Join(batchErr, errors.Wrap(err, "SpannerMigrator.auditBatch()"))
+			}
+
+			return errors.Join(batchErr, &DirtyError{MigrationsTable: s.schemaMigrationsTable, Version: batchErr.Version})
+		}
+	}
+
+	if err := driver.SetVersion(int(final.Version), false); err != nil {
+		return errors.Wrapf(err, "database.Driver.SetVersion(): version %d", final.Version)
+	}
+
+	if err := s.recordChecksums(ctx, s.schemaMigrationsTable, sourceURL, plan.Migrations); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.recordChecksums()")
+	}
+	if err := s.auditBatch(ctx, plan.Migrations, started, nil); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.auditBatch()")
+	}
+
+	return nil
+}
+
+// batchStatements flattens the statements of migrations into a single batch.
+// owners holds the migration each statement is from, at the same index.
+func batchStatements(migrations []PlannedMigration) (stmts []string, owners []PlannedMigration, err error) {
+	for _, m := range migrations {
+		for _, stmt := range m.Statements {
+			if stmt.Type != StatementTypeDDL {
+				return nil, nil, errors.Newf("schema migration %d_%s contains DML, which cannot be batched", m.Version, m.Identifier)
+			}
+			stmts = append(stmts, stmt.SQL)
+			owners = append(owners, m)
+		}
+	}
+
+	return stmts, owners, nil
+}
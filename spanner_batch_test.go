@@ -0,0 +1,168 @@
+package dbinitiator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/errors/v5"
+)
+
+func Test_batchStatements(t *testing.T) {
+	t.Parallel()
+
+	accounts := PlannedMigration{
+		Version:    1,
+		Identifier: "create_accounts",
+		Statements: []PlannedStatement{
+			{Type: StatementTypeDDL, SQL: "CREATE TABLE Accounts (Id STRING(36)) PRIMARY KEY(Id)"},
+			{Type: StatementTypeDDL, SQL: "ALTER TABLE Accounts ADD COLUMN Email STRING(MAX)"},
+		},
+	}
+	empty := PlannedMigration{Version: 2, Identifier: "empty"}
+	index := PlannedMigration{
+		Version:    3,
+		Identifier: "create_accounts_email_index",
+		Statements: []PlannedStatement{{Type: StatementTypeDDL, SQL: "CREATE INDEX Accounts_Email ON Accounts(Email)"}},
+	}
+	seed := PlannedMigration{
+		Version:    4,
+		Identifier: "seed_accounts",
+		Statements: []PlannedStatement{{Type: StatementTypeDML, SQL: "INSERT INTO Accounts (Id) VALUES ('a')"}},
+	}
+
+	tests := []struct {
+		name       string
+		migrations []PlannedMigration
+		wantStmts  []string
+		wantOwners []PlannedMigration
+		wantErr    bool
+	}{
+		{
+			name:       "statements of all migrations in order",
+			migrations: []PlannedMigration{accounts, empty, index},
+			wantStmts: []string{
+				"CREATE TABLE Accounts (Id STRING(36)) PRIMARY KEY(Id)",
+				"ALTER TABLE Accounts ADD COLUMN Email STRING(MAX)",
+				"CREATE INDEX Accounts_Email ON Accounts(Email)",
+			},
+			wantOwners: []PlannedMigration{accounts, accounts, index},
+		},
+		{
+			name:       "DML cannot be batched",
+			migrations: []PlannedMigration{accounts, seed},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stmts, owners, err := batchStatements(tt.migrations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("batchStatements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(stmts, tt.wantStmts) {
+				t.Errorf("batchStatements() stmts = %v, want %v", stmts, tt.wantStmts)
+			}
+			if !reflect.DeepEqual(owners, tt.wantOwners) {
+				t.Errorf("batchStatements() owners = %v, want %v", owners, tt.wantOwners)
+			}
+		})
+	}
+}
+
+func TestSpannerMigrator_MigrateUpSchemaBatched(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	tests := []struct {
+		name          string
+		sourceURL     string
+		wantBatchErr  *BatchDDLError
+		wantVersion   uint
+		wantDirty     bool
+		wantStatement string
+	}{
+		{
+			name:        "applies all pending migrations as one batch",
+			sourceURL:   "file://testdata/spanner/migrations_versioned",
+			wantVersion: 3,
+		},
+		{
+			name:          "reports the failed statement",
+			sourceURL:     "file://testdata/spanner/migrations_batch_error",
+			wantBatchErr:  &BatchDDLError{Version: 2, Identifier: "create_accounts_email_index"},
+			wantVersion:   2,
+			wantDirty:     true,
+			wantStatement: "CREATE INDEX Accounts_Email ON Accounts(Email)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dbName := genDBName()
+			db, err := container.CreateDatabase(ctx, dbName)
+			if err != nil {
+				t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+			}
+			defer func() {
+				if err := db.DropDatabase(context.Background()); err != nil {
+					t.Errorf("DB.DropDatabase() err=%s", err)
+				}
+				if err := db.Close(); err != nil {
+					t.Errorf("DB.Close() err=%s", err)
+				}
+			}()
+
+			svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+			if err != nil {
+				t.Fatalf("NewSpannerMigrator() error = %v", err)
+			}
+			defer func() {
+				if err := svc.Close(); err != nil {
+					t.Errorf("SpannerMigrator.Close() err=%s", err)
+				}
+			}()
+			svc.WithBatchedSchemaMigrations()
+
+			err = svc.MigrateUpSchema(ctx, tt.sourceURL)
+			if tt.wantBatchErr == nil {
+				if err != nil {
+					t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+				}
+			} else {
+				var batchErr *BatchDDLError
+				if !errors.As(err, &batchErr) {
+					t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v, want *BatchDDLError", err)
+				}
+				if batchErr.Version != tt.wantBatchErr.Version || batchErr.Identifier != tt.wantBatchErr.Identifier {
+					t.Errorf("BatchDDLError migration = %d_%s, want %d_%s", batchErr.Version, batchErr.Identifier, tt.wantBatchErr.Version, tt.wantBatchErr.Identifier)
+				}
+				if strings.TrimSpace(batchErr.Statement) != tt.wantStatement {
+					t.Errorf("BatchDDLError.Statement = %q, want %q", batchErr.Statement, tt.wantStatement)
+				}
+				var dirtyErr *DirtyError
+				if !errors.As(err, &dirtyErr) {
+					t.Errorf("SpannerMigrator.MigrateUpSchema() error = %v, want *DirtyError", err)
+				}
+			}
+
+			status, err := svc.SchemaStatus(ctx, tt.sourceURL)
+			if err != nil {
+				t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+			}
+			if status.Version != tt.wantVersion || status.Dirty != tt.wantDirty {
+				t.Errorf("SpannerMigrator.SchemaStatus() = %s, want version %d, dirty %v", status, tt.wantVersion, tt.wantDirty)
+			}
+		})
+	}
+}
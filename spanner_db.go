@@ -3,6 +3,8 @@ package dbinitiator
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"slices"
 
 	"cloud.google.com/go/spanner"
 	spannerDB "cloud.google.com/go/spanner/admin/database/apiv1"
@@ -18,9 +20,11 @@ import (
 
 // SpannerDB represents a database created and ready for migrations
 type SpannerDB struct {
-	dbStr      string
-	admin      *spannerDB.DatabaseAdminClient
-	closeAdmin bool
+	dbStr        string
+	admin        *spannerDB.DatabaseAdminClient
+	closeAdmin   bool
+	resetVersion bool
+	sourceFS     fs.FS
 	*spanner.Client
 }
 
@@ -31,7 +35,7 @@ func NewSpannerDatabase(ctx context.Context, projectID, instanceID, dbName strin
 		return nil, errors.Wrap(err, "database.NewDatabaseAdminClient()")
 	}
 
-	db, err := newSpannerDatabase(ctx, adminClient, projectID, instanceID, dbName, opts...)
+	db, err := newSpannerDatabase(ctx, adminClient, projectID, instanceID, dbName, nil, opts...)
 	if err != nil {
 		if closeErr := adminClient.Close(); closeErr != nil {
 			return nil, errors.Wrap(errors.Join(err, closeErr), "spannerDB.DatabaseAdminClient.Close()")
@@ -45,7 +49,8 @@ func NewSpannerDatabase(ctx context.Context, projectID, instanceID, dbName strin
 	return db, nil
 }
 
-func newSpannerDatabase(ctx context.Context, adminClient *spannerDB.DatabaseAdminClient, projectID, instanceID, dbName string, opts ...option.ClientOption) (*SpannerDB, error) {
+// newSpannerDatabase creates a database with dbName, running extraStatements as part of its creation
+func newSpannerDatabase(ctx context.Context, adminClient *spannerDB.DatabaseAdminClient, projectID, instanceID, dbName string, extraStatements []string, opts ...option.ClientOption) (*SpannerDB, error) {
 	dbStr := fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, dbName)
 	client, err := spanner.NewClientWithConfig(ctx, dbStr, spanner.ClientConfig{DisableNativeMetrics: true}, opts...)
 	if err != nil {
@@ -56,6 +61,7 @@ func newSpannerDatabase(ctx context.Context, adminClient *spannerDB.DatabaseAdmi
 		&databasepb.CreateDatabaseRequest{
 			Parent:          fmt.Sprintf("projects/%s/instances/%s", projectID, instanceID),
 			CreateStatement: fmt.Sprintf("CREATE DATABASE `%s`", dbName),
+			ExtraStatements: extraStatements,
 		},
 	)
 	if err != nil {
@@ -73,7 +79,27 @@ func newSpannerDatabase(ctx context.Context, adminClient *spannerDB.DatabaseAdmi
 	}, nil
 }
 
-// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's
+// WithVersionReset makes MigrateUp clear the migration version before applying each source.
+// This allows several independent sources, each numbered from 1, to be applied in sequence.
+func (db *SpannerDB) WithVersionReset() *SpannerDB {
+	db.resetVersion = true
+
+	return db
+}
+
+// WithSourceFS reads migrations from fsys, e.g. an embed.FS compiled into the test binary.
+// The sourceURL of every migrate method is then the path of a migrations directory within fsys.
+func (db *SpannerDB) WithSourceFS(fsys fs.FS) *SpannerDB {
+	db.sourceFS = fsys
+
+	return db
+}
+
+// MigrateUp will migrate all the way up, applying all up migrations from all sourceURL's.
+// A *DirtyError is returned if a previous migration failed part way through.
+//
+// The version carries over from one source to the next, so an error is returned for a source that ends at or before
+// the current version. Use WithVersionReset to apply several sources that are each numbered from 1.
 func (db *SpannerDB) MigrateUp(sourceURL ...string) error {
 	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true}
 	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *db.Client), conf)
@@ -91,20 +117,22 @@ func (db *SpannerDB) MigrateUp(sourceURL ...string) error {
 }
 
 func (db *SpannerDB) migrateUp(source string, spannerInstance migratedb.Driver) error {
-	m, err := migrate.NewWithDatabaseInstance(source, "spanner", spannerInstance)
+	m, err := db.newMigrateWithInstance(source, spannerInstance)
 	if err != nil {
-		return errors.Wrapf(err, "migrate.NewWithDatabaseInstance(): fileURL=%s, db=%s", source, db.dbStr)
+		return err
 	}
 	defer m.Close()
 
-	if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
-		if err := m.Force(-1); err != nil {
-			return errors.Wrapf(err, "migrate.Migrate.Force(): %s", source)
+	if db.resetVersion {
+		if err := resetVersion(m, spannerDriver.DefaultMigrationsTable); err != nil {
+			return errors.Wrapf(err, "resetVersion(): %s", source)
 		}
+	} else if err := checkSourceAhead(m, db.sourceFS, source); err != nil {
+		return err
 	}
 
 	if err := m.Up(); err != nil {
-		return errors.Wrapf(err, "migrate.Migrate.Up(): %s", source)
+		return errors.Wrapf(dirtyError(m, spannerDriver.DefaultMigrationsTable, err), "migrate.Migrate.Up(): %s", source)
 	}
 
 	if err, dbErr := m.Close(); err != nil {
@@ -118,20 +146,14 @@ func (db *SpannerDB) migrateUp(source string, spannerInstance migratedb.Driver)
 
 // MigrateDown will migrate all the way down
 func (db *SpannerDB) MigrateDown(sourceURL string) error {
-	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true}
-	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *db.Client), conf)
-	if err != nil {
-		return errors.Wrap(err, "spannerDriver.WithInstance()")
-	}
-
-	m, err := migrate.NewWithDatabaseInstance(sourceURL, "spanner", spannerInstance)
+	m, err := db.newMigrate(sourceURL)
 	if err != nil {
-		return errors.Wrapf(err, "migrate.NewWithDatabaseInstance(): fileURL=%s, db=%s", sourceURL, db.dbStr)
+		return err
 	}
 	defer m.Close()
 
 	if err := m.Down(); err != nil {
-		return errors.Wrap(err, "migrate.Migrate.Down()")
+		return errors.Wrap(dirtyError(m, spannerDriver.DefaultMigrationsTable, err), "migrate.Migrate.Down()")
 	}
 
 	if err, dbErr := m.Close(); err != nil {
@@ -143,6 +165,136 @@ func (db *SpannerDB) MigrateDown(sourceURL string) error {
 	return nil
 }
 
+// MigrateTo will migrate up or down to version
+func (db *SpannerDB) MigrateTo(sourceURL string, version uint) error {
+	m, err := db.newMigrate(sourceURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Migrate(version); err != nil {
+		return errors.Wrapf(dirtyError(m, spannerDriver.DefaultMigrationsTable, err), "migrate.Migrate.Migrate(): version %d", version)
+	}
+
+	return nil
+}
+
+// MigrateSteps will apply n migrations. A negative n migrates down.
+func (db *SpannerDB) MigrateSteps(sourceURL string, n int) error {
+	m, err := db.newMigrate(sourceURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(n); err != nil {
+		return errors.Wrapf(dirtyError(m, spannerDriver.DefaultMigrationsTable, err), "migrate.Migrate.Steps(): n=%d", n)
+	}
+
+	return nil
+}
+
+// ForceVersion sets the migration version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (db *SpannerDB) ForceVersion(sourceURL string, version int) error {
+	m, err := db.newMigrate(sourceURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Force(): version %d", version)
+	}
+
+	return nil
+}
+
+// RetryDirty runs the failed up migration that left the database dirty again.
+// Any statements of the migration that were applied before it failed must be reverted by hand first.
+func (db *SpannerDB) RetryDirty(sourceURL string) error {
+	m, err := db.newMigrate(sourceURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	src, err := openSource(db.sourceFS, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := retryDirty(m, src); err != nil {
+		return dirtyError(m, spannerDriver.DefaultMigrationsTable, err)
+	}
+
+	return nil
+}
+
+func (db *SpannerDB) newMigrate(sourceURL string) (*migrate.Migrate, error) {
+	conf := &spannerDriver.Config{DatabaseName: db.dbStr, CleanStatements: true}
+	spannerInstance, err := spannerDriver.WithInstance(spannerDriver.NewDB(*db.admin, *db.Client), conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "spannerDriver.WithInstance()")
+	}
+
+	return db.newMigrateWithInstance(sourceURL, spannerInstance)
+}
+
+func (db *SpannerDB) newMigrateWithInstance(sourceURL string, spannerInstance migratedb.Driver) (*migrate.Migrate, error) {
+	src, err := openSource(db.sourceFS, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance(sourceURL, src, "spanner", spannerInstance)
+	if err != nil {
+		_ = src.Close()
+
+		return nil, errors.Wrapf(err, "migrate.NewWithInstance(): fileURL=%s, db=%s", sourceURL, db.dbStr)
+	}
+
+	return m, nil
+}
+
+// Truncate deletes all rows from every table and keeps the schema. Interleaved children are deleted before their
+// parents and, unless the references have a cycle, referencing tables before the tables they reference, all in a
+// single commit.
+//
+// The migration tables are kept, so the migration versions are unchanged. Force the data version back before
+// re-seeding data migrations.
+func (db *SpannerDB) Truncate(ctx context.Context) error {
+	tables, err := spannerTableDependencies(ctx, db.Client)
+	if err != nil {
+		return err
+	}
+
+	sorted, err := sortTableDrops(tables)
+	if err != nil {
+		return err
+	}
+
+	tracking := spannerTrackingTables(spannerDriver.DefaultMigrationsTable, spannerDataMigrationsTable)
+	mutations := make([]*spanner.Mutation, 0, len(sorted))
+	for _, t := range sorted {
+		if slices.Contains(tracking, t.qualifiedName()) {
+			continue
+		}
+		mutations = append(mutations, spanner.Delete(t.qualifiedName(), spanner.AllKeys()))
+	}
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	if _, err := db.Client.Apply(ctx, mutations); err != nil {
+		return errors.Wrap(err, "spanner.Client.Apply()")
+	}
+
+	return nil
+}
+
 func (db *SpannerDB) DropDatabase(ctx context.Context) error {
 	if err := db.admin.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: db.dbStr}); err != nil {
 		return errors.Wrap(err, "database.DatabaseAdminClient.DropDatabase()")
@@ -166,6 +318,11 @@ func (db *SpannerDB) Close() error {
 
 // NewSpannerInstance creates a spanner instance. This is intended for use with a spanner emulator.
 func NewSpannerInstance(ctx context.Context, projectID, instanceID string, opts ...option.ClientOption) error {
+	return newSpannerInstance(ctx, projectID, instanceID, "", opts...)
+}
+
+// newSpannerInstance creates a spanner instance with instanceConfig, or the default configuration when it is empty
+func newSpannerInstance(ctx context.Context, projectID, instanceID, instanceConfig string, opts ...option.ClientOption) error {
 	instanceAdmin, err := instance.NewInstanceAdminClient(ctx, opts...)
 	if err != nil {
 		return errors.Wrap(err, "instanceadmin.NewInstanceAdminClient()")
@@ -178,6 +335,7 @@ func NewSpannerInstance(ctx context.Context, projectID, instanceID string, opts
 			InstanceId: instanceID,
 			Instance: &instanceadm.Instance{
 				DisplayName: instanceID,
+				Config:      instanceConfigName(projectID, instanceConfig),
 			},
 		},
 	)
@@ -195,3 +353,11 @@ func NewSpannerInstance(ctx context.Context, projectID, instanceID string, opts
 
 	return nil
 }
+
+func instanceConfigName(projectID, instanceConfig string) string {
+	if instanceConfig == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("projects/%s/instanceConfigs/%s", projectID, instanceConfig)
+}
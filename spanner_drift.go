@@ -0,0 +1,88 @@
+package dbinitiator
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+)
+
+// SchemaDrift applies the schema migrations from sourceURL to a scratch database in container and compares its DDL
+// with the DDL of the database the migrator points at. Tables used to track migrations are ignored.
+//
+// The scratch database is dropped before returning.
+func (s *SpannerMigrator) SchemaDrift(ctx context.Context, container *SpannerContainer, sourceURL string) (*SchemaDrift, error) {
+	ccclogger.FromCtx(ctx).Infof("Checking schema drift against migrations from %s", sourceURL)
+
+	dbName := container.validDatabaseName("drift-" + strconv.FormatInt(time.Now().UnixNano(), 36))
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerContainer.CreateDatabase()")
+	}
+	defer func() {
+		_ = db.DropDatabase(context.Background())
+		_ = db.Close()
+	}()
+
+	scratch, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSpannerMigrator()")
+	}
+	defer scratch.Close()
+
+	scratch.schemaMigrationsTable = s.schemaMigrationsTable
+	scratch.dataMigrationsTable = s.dataMigrationsTable
+	scratch.sourceFS = s.sourceFS
+
+	if err := scratch.MigrateUpSchema(ctx, sourceURL); err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.MigrateUpSchema()")
+	}
+
+	expected, err := scratch.databaseDDL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := s.databaseDDL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSchemaDrift(expected, live), nil
+}
+
+// databaseDDL returns the DDL statements of the database, without the tables used to track migrations
+func (s *SpannerMigrator) databaseDDL(ctx context.Context) ([]string, error) {
+	resp, err := s.admin.GetDatabaseDdl(ctx, &adminpb.GetDatabaseDdlRequest{Database: s.connectionString})
+	if err != nil {
+		return nil, errors.Wrapf(err, "SpannerMigrator.admin.GetDatabaseDdl(): %s", s.connectionString)
+	}
+
+	tracking := s.trackingTables()
+	stmts := make([]string, 0, len(resp.GetStatements()))
+	for _, stmt := range resp.GetStatements() {
+		if slices.Contains(tracking, ddlTableName(stmt)) {
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts, nil
+}
+
+// ddlTableName returns the table a CREATE TABLE statement creates, or an empty string for any other statement
+func ddlTableName(stmt string) string {
+	fields := strings.Fields(stmt)
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "CREATE") || !strings.EqualFold(fields[1], "TABLE") {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(fields[2], "(")
+
+	return strings.Trim(name, "`")
+}
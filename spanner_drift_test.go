@@ -0,0 +1,97 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+func Test_ddlTableName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{name: "create table", stmt: "CREATE TABLE SchemaMigrations (\n  Version INT64 NOT NULL,\n) PRIMARY KEY(Version)", want: "SchemaMigrations"},
+		{name: "quoted name", stmt: "CREATE TABLE `Order`(Id STRING(36)) PRIMARY KEY(Id)", want: "Order"},
+		{name: "create index", stmt: "CREATE INDEX Accounts_Email ON Accounts(Email)", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ddlTableName(tt.stmt); got != tt.want {
+				t.Errorf("ddlTableName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerMigrator_SchemaDrift(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	sourceURL := "file://testdata/spanner/migrations_versioned"
+	if err := svc.MigrateUpSchema(ctx, sourceURL); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	drift, err := svc.SchemaDrift(ctx, container, sourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaDrift() error = %v", err)
+	}
+	if drift.HasDrift() {
+		t.Errorf("SpannerMigrator.SchemaDrift() = %s, want no drift", drift)
+	}
+
+	op, err := svc.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   svc.connectionString,
+		Statements: []string{"CREATE INDEX Accounts_Name ON Accounts(Name)"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateDatabaseDdl() error = %v", err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		t.Fatalf("UpdateDatabaseDdl().Wait() error = %v", err)
+	}
+
+	drift, err = svc.SchemaDrift(ctx, container, sourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaDrift() error = %v", err)
+	}
+	if len(drift.Missing) != 0 || len(drift.Unexpected) != 1 || drift.Unexpected[0] != "CREATE INDEX Accounts_Name ON Accounts(Name)" {
+		t.Errorf("SpannerMigrator.SchemaDrift() = %s, want only the hand-made index", drift)
+	}
+}
@@ -0,0 +1,168 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	adminpb "cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/iterator"
+)
+
+// WithChecksumPolicy records a checksum of every migration file as it is applied, in a history table named after
+// the migrations table with a "History" suffix, e.g. "SchemaMigrationsHistory".
+//
+// Before migrating, the files of applied migrations are compared with their recorded checksums and policy decides
+// whether a changed file fails the migration or is logged. Migrations applied before the policy was set have no checksum.
+func (s *SpannerMigrator) WithChecksumPolicy(policy ChecksumPolicy) *SpannerMigrator {
+	s.checksumPolicy = policy
+
+	return s
+}
+
+// verifyChecksums checks the applied migrations of migrationsTable against the files in sourceURL
+func (s *SpannerMigrator) verifyChecksums(ctx context.Context, migrationsTable, sourceURL string) error {
+	if s.checksumPolicy == 0 {
+		return nil
+	}
+
+	history, err := s.newHistory(ctx, migrationsTable)
+	if err != nil {
+		return err
+	}
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return verifyChecksums(ctx, history, src, migrationsTable, s.checksumPolicy)
+}
+
+// spannerHistory stores migration checksums in a Spanner table
+type spannerHistory struct {
+	client *spanner.Client
+	table  string
+}
+
+var _ migrationHistory = (*spannerHistory)(nil)
+
+// newHistory returns the history of migrationsTable, creating its table if it does not exist
+func (s *SpannerMigrator) newHistory(ctx context.Context, migrationsTable string) (*spannerHistory, error) {
+	h := &spannerHistory{client: s.client, table: migrationsTable + "History"}
+
+	if exists, err := spannerTableExists(ctx, s.client, h.table, "Version"); err != nil {
+		return nil, err
+	} else if exists {
+		return h, nil
+	}
+
+	stmt := fmt.Sprintf(`CREATE TABLE `+"`%s`"+` (
+		Version INT64 NOT NULL,
+		Identifier STRING(MAX) NOT NULL,
+		Checksum STRING(64) NOT NULL,
+		AppliedAt TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp = true),
+	) PRIMARY KEY (Version)`, h.table)
+
+	op, err := s.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   s.connectionString,
+		Statements: []string{stmt},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "SpannerMigrator.admin.UpdateDatabaseDdl(): %s", h.table)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return nil, errors.Wrapf(err, "SpannerMigrator.admin.UpdateDatabaseDdl().Wait(): %s", h.table)
+	}
+
+	return h, nil
+}
+
+func (h *spannerHistory) checksums(ctx context.Context) (map[uint]string, error) {
+	iter := h.client.Single().Read(ctx, h.table, spanner.AllKeys(), []string{"Version", "Checksum"})
+	defer iter.Stop()
+
+	checksums := make(map[uint]string)
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
+		}
+
+		var version int64
+		var checksum string
+		if err := row.Columns(&version, &checksum); err != nil {
+			return nil, errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		checksums[uint(version)] = checksum
+	}
+
+	return checksums, nil
+}
+
+func (h *spannerHistory) record(ctx context.Context, version uint, identifier, checksum string) error {
+	m := spanner.InsertOrUpdate(h.table,
+		[]string{"Version", "Identifier", "Checksum", "AppliedAt"},
+		[]any{int64(version), identifier, checksum, spanner.CommitTimestamp},
+	)
+	if _, err := h.client.Apply(ctx, []*spanner.Mutation{m}); err != nil {
+		return errors.Wrap(err, "spanner.Client.Apply()")
+	}
+
+	return nil
+}
+
+func (h *spannerHistory) removeAfter(ctx context.Context, version int) error {
+	stmt := spanner.Statement{
+		SQL:    fmt.Sprintf("DELETE FROM %s WHERE Version > @version", h.table),
+		Params: map[string]any{"version": int64(version)},
+	}
+	if _, err := h.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		if _, err := txn.Update(ctx, stmt); err != nil {
+			return errors.Wrap(err, "spanner.ReadWriteTransaction.Update()")
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "spanner.Client.ReadWriteTransaction()")
+	}
+
+	return nil
+}
+
+func (h *spannerHistory) close() {}
+
+// recordChecksums records the checksums of migrations that were applied without a historyDriver
+func (s *SpannerMigrator) recordChecksums(ctx context.Context, migrationsTable, sourceURL string, migrations []PlannedMigration) error {
+	if s.checksumPolicy == 0 || len(migrations) == 0 {
+		return nil
+	}
+
+	history, err := s.newHistory(ctx, migrationsTable)
+	if err != nil {
+		return err
+	}
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	for _, m := range migrations {
+		migr, identifier, err := readUpMigration(src, m.Version)
+		if err != nil {
+			return err
+		}
+		if err := history.record(ctx, m.Version, identifier, migrationChecksum(migr)); err != nil {
+			return errors.Wrapf(err, "migrationHistory.record(): version %d", m.Version)
+		}
+	}
+
+	return nil
+}
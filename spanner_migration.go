@@ -3,6 +3,9 @@ package dbinitiator
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
 
 	"cloud.google.com/go/spanner"
 	spannerDB "cloud.google.com/go/spanner/admin/database/apiv1"
@@ -13,16 +16,26 @@ import (
 	"github.com/golang-migrate/migrate/v4/database"
 	spannerDriver "github.com/golang-migrate/migrate/v4/database/spanner"
 	_ "github.com/golang-migrate/migrate/v4/source/file" // up/down script file source driver for the migrate package
-	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+// spannerDataMigrationsTable is the default table of the data migrations track. The schema migrations track
+// defaults to [spannerDriver.DefaultMigrationsTable].
+const spannerDataMigrationsTable = "DataMigrations"
+
 // SpannerMigrator handles connecting to an existing spanner database and running migrations
 type SpannerMigrator struct {
 	connectionString      string
 	dataMigrationsTable   string
 	schemaMigrationsTable string
 	databaseName          string
+	sourceFS              fs.FS
+	dataMigrationFuncs    map[uint]spannerGoMigration
+	batchSchemaMigrations bool
+	checksumPolicy        ChecksumPolicy
+	auditLog              bool
+	operator              string
+	dropFilter            DropFilter
 	admin                 *spannerDB.DatabaseAdminClient
 	client                *spanner.Client
 }
@@ -49,8 +62,8 @@ func NewSpannerMigrator(ctx context.Context, projectID, instanceID, dbName strin
 	}
 
 	return &SpannerMigrator{
-		dataMigrationsTable:   "DataMigrations",
-		schemaMigrationsTable: "SchemaMigrations",
+		dataMigrationsTable:   spannerDataMigrationsTable,
+		schemaMigrationsTable: spannerDriver.DefaultMigrationsTable,
 		connectionString:      dbStr,
 		databaseName:          dbName,
 		admin:                 adminClient,
@@ -72,12 +85,35 @@ func (s *SpannerMigrator) WithDataMigrationsTable(table string) *SpannerMigrator
 	return s
 }
 
+// WithSourceFS reads migrations from fsys, e.g. an embed.FS compiled into the binary.
+// The sourceURL of every method is then the path of a migrations directory within fsys.
+func (s *SpannerMigrator) WithSourceFS(fsys fs.FS) *SpannerMigrator {
+	s.sourceFS = fsys
+
+	return s
+}
+
+// WithDropFilter limits MigrateDropSchema and DropSchemaStatements to the tables and views selected by filter
+func (s *SpannerMigrator) WithDropFilter(filter DropFilter) *SpannerMigrator {
+	s.dropFilter = filter
+
+	return s
+}
+
 // MigrateUpSchema will migrate all the way up, applying all up migrations from the sourceURL
 //
-// Use for DDL migrations
+// Use for DDL migrations. See [SpannerMigrator.WithBatchedSchemaMigrations] to apply them as a single batch.
 func (s *SpannerMigrator) MigrateUpSchema(ctx context.Context, sourceURL string) error {
 	ccclogger.FromCtx(ctx).Infof("Applying schema migrations from %s", sourceURL)
-	if err := s.migrateUp(s.schemaMigrationsTable, sourceURL); err != nil {
+	if s.batchSchemaMigrations {
+		if err := s.migrateUpBatch(ctx, sourceURL); err != nil {
+			return errors.Wrap(err, "SpannerMigrator.migrateUpBatch()")
+		}
+
+		return nil
+	}
+
+	if err := s.migrateUp(ctx, s.schemaMigrationsTable, sourceURL); err != nil {
 		return errors.Wrap(err, "SpannerMigrator.migrateUp()")
 	}
 
@@ -86,55 +122,143 @@ func (s *SpannerMigrator) MigrateUpSchema(ctx context.Context, sourceURL string)
 
 // MigrateUpData will apply all data migrations from the sourceURL
 //
-// Use for DML migrations
+// Use for DML migrations. Files marked with [PartitionedDMLDirective] are run as Partitioned DML.
 func (s *SpannerMigrator) MigrateUpData(ctx context.Context, sourceURL string) error {
 	ccclogger.FromCtx(ctx).Infof("Applying data migrations from %s", sourceURL)
-	if err := s.migrateUp(s.dataMigrationsTable, sourceURL); err != nil {
+	if err := s.migrateUp(ctx, s.dataMigrationsTable, sourceURL); err != nil {
 		return errors.Wrap(err, "SpannerMigrator.migrateUp()")
 	}
 
 	return nil
 }
 
-// MigrateDropSchema drops all objects in the schema
+// MigrateSchemaTo migrates the schema up or down to version, applying the migrations from the sourceURL
+func (s *SpannerMigrator) MigrateSchemaTo(ctx context.Context, sourceURL string, version uint) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating schema to version %d from %s", version, sourceURL)
+	if err := s.migrateTo(ctx, s.schemaMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.migrateTo()")
+	}
+
+	return nil
+}
+
+// MigrateDataTo migrates the data up or down to version, applying the migrations from the sourceURL
+func (s *SpannerMigrator) MigrateDataTo(ctx context.Context, sourceURL string, version uint) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating data to version %d from %s", version, sourceURL)
+	if err := s.migrateTo(ctx, s.dataMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.migrateTo()")
+	}
+
+	return nil
+}
+
+// MigrateSchemaSteps applies n schema migrations from the sourceURL. A negative n migrates down.
+func (s *SpannerMigrator) MigrateSchemaSteps(ctx context.Context, sourceURL string, n int) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating schema %d step(s) from %s", n, sourceURL)
+	if err := s.migrateSteps(ctx, s.schemaMigrationsTable, sourceURL, n); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.migrateSteps()")
+	}
+
+	return nil
+}
+
+// MigrateDataSteps applies n data migrations from the sourceURL. A negative n migrates down.
+func (s *SpannerMigrator) MigrateDataSteps(ctx context.Context, sourceURL string, n int) error {
+	ccclogger.FromCtx(ctx).Infof("Migrating data %d step(s) from %s", n, sourceURL)
+	if err := s.migrateSteps(ctx, s.dataMigrationsTable, sourceURL, n); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.migrateSteps()")
+	}
+
+	return nil
+}
+
+// ForceSchemaVersion sets the schema migrations version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (s *SpannerMigrator) ForceSchemaVersion(ctx context.Context, sourceURL string, version int) error {
+	ccclogger.FromCtx(ctx).Infof("Forcing schema version %d", version)
+	if err := s.forceVersion(ctx, s.schemaMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.forceVersion()")
+	}
+
+	return nil
+}
+
+// ForceDataVersion sets the data migrations version and clears the dirty flag without running any migration.
+// Use it once a failed migration has been repaired by hand. A version of -1 clears the version.
+func (s *SpannerMigrator) ForceDataVersion(ctx context.Context, sourceURL string, version int) error {
+	ccclogger.FromCtx(ctx).Infof("Forcing data version %d", version)
+	if err := s.forceVersion(ctx, s.dataMigrationsTable, sourceURL, version); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.forceVersion()")
+	}
+
+	return nil
+}
+
+// RetryDirtySchema runs the failed schema migration that left the schema migrations dirty again.
 //
-// This happens in the following order:
-//  1. Drop views
-//  2. Drop FK constraints
-//  3. Drop Search Indexes
-//  4. Drop Indexes
-//  5. Drop tables
-func (s *SpannerMigrator) MigrateDropSchema(ctx context.Context) error {
-	stmts := make([]string, 0, 10)
-	viewDropStatements, err := s.viewDropStatements(ctx)
-	if err != nil {
-		return err
+// Spanner DDL is not transactional, so statements of the migration that were applied before it failed
+// must be reverted by hand first.
+func (s *SpannerMigrator) RetryDirtySchema(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Retrying dirty schema migration from %s", sourceURL)
+	if err := s.retryDirty(ctx, s.schemaMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.retryDirty()")
 	}
-	stmts = append(stmts, viewDropStatements...)
 
-	foreignKeyDropStatements, err := s.foreignKeyDropStatements(ctx)
-	if err != nil {
-		return err
+	return nil
+}
+
+// RetryDirtyData runs the failed data migration that left the data migrations dirty again.
+func (s *SpannerMigrator) RetryDirtyData(ctx context.Context, sourceURL string) error {
+	ccclogger.FromCtx(ctx).Infof("Retrying dirty data migration from %s", sourceURL)
+	if err := s.retryDirty(ctx, s.dataMigrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.retryDirty()")
 	}
-	stmts = append(stmts, foreignKeyDropStatements...)
 
-	searchIndexDropStatements, err := s.searchIndexDropStatements(ctx)
+	return nil
+}
+
+// SchemaStatus reports the current version, dirty flag and pending migrations of the schema migrations in sourceURL
+func (s *SpannerMigrator) SchemaStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error) {
+	status, err := s.status(ctx, s.schemaMigrationsTable, sourceURL)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "SpannerMigrator.status()")
 	}
-	stmts = append(stmts, searchIndexDropStatements...)
 
-	indexDropStatements, err := s.indexDropStatements(ctx)
+	return status, nil
+}
+
+// DataStatus reports the current version, dirty flag and pending migrations of the data migrations in sourceURL
+func (s *SpannerMigrator) DataStatus(ctx context.Context, sourceURL string) (*MigrationStatus, error) {
+	status, err := s.status(ctx, s.dataMigrationsTable, sourceURL)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "SpannerMigrator.status()")
 	}
-	stmts = append(stmts, indexDropStatements...)
 
-	tableDropStatements, err := s.tableDropStatements(ctx)
+	return status, nil
+}
+
+// MigrateDropSchema drops all objects in the schema
+//
+// This happens in the following order:
+//  1. Revoke privileges and role memberships granted to database roles
+//  2. Drop database roles
+//  3. Drop change streams
+//  4. Drop views
+//  5. Drop property graphs
+//  6. Drop models
+//  7. Drop FK constraints
+//  8. Drop Search Indexes
+//  9. Drop Indexes
+//  10. Drop tables
+//  11. Drop sequences
+//  12. Drop named schemas
+//
+// See [SpannerMigrator.WithDropFilter] to drop only some of the tables.
+func (s *SpannerMigrator) MigrateDropSchema(ctx context.Context) error {
+	stmts, err := s.DropSchemaStatements(ctx)
 	if err != nil {
 		return err
 	}
-	stmts = append(stmts, tableDropStatements...)
 
 	if len(stmts) > 0 {
 		op, err := s.admin.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
@@ -154,6 +278,127 @@ func (s *SpannerMigrator) MigrateDropSchema(ctx context.Context) error {
 	return nil
 }
 
+// DropSchemaStatements returns the statements MigrateDropSchema runs, in order, without running them
+func (s *SpannerMigrator) DropSchemaStatements(ctx context.Context) ([]string, error) {
+	if err := s.dropFilter.validate(); err != nil {
+		return nil, err
+	}
+
+	stmts, err := s.dropStatements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.dropFilter.isZero() {
+		return filterDropStatements(stmts, nil), nil
+	}
+
+	objects, err := s.dropObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dropped, err := droppedObjects(s.dropFilter, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterDropStatements(stmts, dropped), nil
+}
+
+// dropStatements returns the statements that drop all objects in the schema, in the order MigrateDropSchema runs them
+func (s *SpannerMigrator) dropStatements(ctx context.Context) ([]dropStatement, error) {
+	stmts := make([]dropStatement, 0, 10)
+	for _, dropStatements := range []func(context.Context) ([]dropStatement, error){
+		s.grantRevokeStatements,
+		s.roleDropStatements,
+		s.changeStreamDropStatements,
+		s.viewDropStatements,
+		s.propertyGraphDropStatements,
+		s.modelDropStatements,
+		s.foreignKeyDropStatements,
+		s.searchIndexDropStatements,
+		s.indexDropStatements,
+		s.tableDropStatements,
+		s.sequenceDropStatements,
+		s.schemaDropStatements,
+	} {
+		objectStmts, err := dropStatements(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, objectStmts...)
+	}
+
+	return stmts, nil
+}
+
+// dropObjects returns the tables and views in the schema. Tables require their interleave parent and views require
+// the tables and views their definition reads from.
+func (s *SpannerMigrator) dropObjects(ctx context.Context) ([]dropObject, error) {
+	tables, err := spannerTableDependencies(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]dropObject, 0, len(tables))
+	for _, t := range tables {
+		o := dropObject{Name: t.qualifiedName()}
+		if t.Parent != "" {
+			o.Requires = append(o.Requires, t.Parent)
+		}
+		objects = append(objects, o)
+	}
+
+	query := `
+		SELECT table_schema, table_name, COALESCE(view_definition, '')
+		FROM information_schema.views
+		WHERE NOT table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')`
+
+	type view struct {
+		name       string
+		definition string
+	}
+	var views []view
+	if err := spannerQueryRows(ctx, s.client, query, func(row *spanner.Row) error {
+		var schema, name, definition string
+		if err := row.Columns(&schema, &name, &definition); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		views = append(views, view{name: qualifiedTableName(schema, name), definition: definition})
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// identifiers are case insensitive
+	names := make(map[string]string, len(objects)+len(views))
+	for _, o := range objects {
+		names[strings.ToLower(o.Name)] = o.Name
+	}
+	for _, v := range views {
+		names[strings.ToLower(v.name)] = v.name
+	}
+
+	for _, v := range views {
+		refs, err := viewTableReferences(v.definition)
+		if err != nil {
+			return nil, errors.Wrapf(err, "view %s", v.name)
+		}
+
+		o := dropObject{Name: v.name}
+		for _, ref := range refs {
+			if name, ok := names[strings.ToLower(ref)]; ok && name != v.name && !slices.Contains(o.Requires, name) {
+				o.Requires = append(o.Requires, name)
+			}
+		}
+		objects = append(objects, o)
+	}
+
+	return objects, nil
+}
+
 // Close cleans up resources
 func (s *SpannerMigrator) Close() error {
 	s.client.Close()
@@ -165,21 +410,115 @@ func (s *SpannerMigrator) Close() error {
 	return nil
 }
 
-func (s *SpannerMigrator) migrateUp(migrationsTable, sourceURL string) error {
-	m, err := s.newMigrate(migrationsTable, sourceURL)
+func (s *SpannerMigrator) migrateUp(ctx context.Context, migrationsTable, sourceURL string) error {
+	if err := s.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.verifyChecksums()")
+	}
+
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, false)
 	if err != nil {
 		return errors.Wrap(err, "SpannerMigrator.newMigrate()")
 	}
 
 	if err := m.Up(); err != nil {
-		return errors.Wrapf(err, "migrate.Migrate.Up(): %s", sourceURL)
+		return errors.Wrapf(dirtyError(m, migrationsTable, err), "migrate.Migrate.Up(): %s", sourceURL)
+	}
+
+	return nil
+}
+
+func (s *SpannerMigrator) migrateTo(ctx context.Context, migrationsTable, sourceURL string, version uint) error {
+	if err := s.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.verifyChecksums()")
+	}
+
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "SpannerMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	if err := m.Migrate(version); err != nil {
+		return errors.Wrapf(dirtyError(m, migrationsTable, err), "migrate.Migrate.Migrate(): version %d: %s", version, sourceURL)
+	}
+
+	return nil
+}
+
+func (s *SpannerMigrator) migrateSteps(ctx context.Context, migrationsTable, sourceURL string, n int) error {
+	if err := s.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.verifyChecksums()")
+	}
+
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "SpannerMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	if err := m.Steps(n); err != nil {
+		return errors.Wrapf(dirtyError(m, migrationsTable, err), "migrate.Migrate.Steps(): n=%d: %s", n, sourceURL)
 	}
 
 	return nil
 }
 
-// newMigrate creates a new migrate instance
-func (s *SpannerMigrator) newMigrate(migrationsTable, sourceURL string) (*migrate.Migrate, error) {
+func (s *SpannerMigrator) forceVersion(ctx context.Context, migrationsTable, sourceURL string, version int) error {
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "SpannerMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return errors.Wrapf(err, "migrate.Migrate.Force(): version %d", version)
+	}
+
+	return nil
+}
+
+func (s *SpannerMigrator) retryDirty(ctx context.Context, migrationsTable, sourceURL string) error {
+	if err := s.verifyChecksums(ctx, migrationsTable, sourceURL); err != nil {
+		return errors.Wrap(err, "SpannerMigrator.verifyChecksums()")
+	}
+
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, false)
+	if err != nil {
+		return errors.Wrap(err, "SpannerMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := retryDirty(m, src); err != nil {
+		return dirtyError(m, migrationsTable, err)
+	}
+
+	return nil
+}
+
+func (s *SpannerMigrator) status(ctx context.Context, migrationsTable, sourceURL string) (*MigrationStatus, error) {
+	m, err := s.newMigrate(ctx, migrationsTable, sourceURL, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.newMigrate()")
+	}
+	defer m.Close()
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return newMigrationStatus(m, src, migrationsTable, sourceURL)
+}
+
+// newDriver creates a spanner driver that stores its version in migrationsTable
+func (s *SpannerMigrator) newDriver(migrationsTable string) (database.Driver, error) {
 	conf := &spannerDriver.Config{DatabaseName: s.connectionString, CleanStatements: true, MigrationsTable: migrationsTable}
 	spannerInstance, err := spannerDriver.WithInstance(
 		spannerDriver.NewDB(*s.admin, *s.client),
@@ -189,140 +528,280 @@ func (s *SpannerMigrator) newMigrate(migrationsTable, sourceURL string) (*migrat
 		return nil, errors.Wrap(err, "spannerDriver.WithInstance()")
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(sourceURL, "spanner", spannerInstance)
+	return spannerInstance, nil
+}
+
+// trackingTables returns the tables the migrator uses to track migrations
+func (s *SpannerMigrator) trackingTables() []string {
+	return spannerTrackingTables(s.schemaMigrationsTable, s.dataMigrationsTable)
+}
+
+// newMigrate creates a new migrate instance. Unless readOnly is set, the history and audit tables are created when
+// they are enabled and missing. A read only instance records nothing, so it must not apply migrations.
+func (s *SpannerMigrator) newMigrate(ctx context.Context, migrationsTable, sourceURL string, readOnly bool) (*migrate.Migrate, error) {
+	spannerInstance, err := s.newDriver(migrationsTable)
 	if err != nil {
-		return nil, errors.Wrapf(err, "migrate.NewWithDatabaseInstance(): fileURL=%s, db=%s", sourceURL, s.connectionString)
+		return nil, err
+	}
+
+	if migrationsTable == s.dataMigrationsTable {
+		spannerInstance = &partitionedDMLDriver{Driver: spannerInstance, ctx: ctx, client: s.client}
+	}
+	if s.hasGoMigrations(migrationsTable) {
+		spannerInstance = &goMigrationDriver{Driver: spannerInstance, ctx: ctx, run: s.runGoMigration}
+	}
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !readOnly && (s.checksumPolicy != 0 || s.auditLog) {
+		driver := &historyDriver{Driver: spannerInstance, ctx: ctx, src: src, operator: s.operatorName()}
+		if s.checksumPolicy != 0 {
+			if driver.history, err = s.newHistory(ctx, migrationsTable); err != nil {
+				_ = src.Close()
+
+				return nil, err
+			}
+		}
+		if s.auditLog {
+			if driver.audit, err = s.newAuditLog(ctx, migrationsTable); err != nil {
+				_ = src.Close()
+
+				return nil, err
+			}
+		}
+		spannerInstance = driver
+	}
+
+	m, err := migrate.NewWithInstance(sourceURL, src, "spanner", spannerInstance)
+	if err != nil {
+		_ = src.Close()
+
+		return nil, errors.Wrapf(err, "migrate.NewWithInstance(): fileURL=%s, db=%s", sourceURL, s.connectionString)
 	}
 	m.Log = new(logger)
 
 	return m, nil
 }
 
-func (s *SpannerMigrator) viewDropStatements(ctx context.Context) ([]string, error) {
-	query := `
-		SELECT CONCAT('DROP VIEW ` + "`" + `', TABLE_NAME, '` + "`" + `') AS ddl
-		FROM information_schema.tables
-		WHERE NOT TABLE_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
-		  AND TABLE_TYPE = 'VIEW'
-		ORDER BY TABLE_NAME`
+// spannerQualifiedName returns a SQL expression that quotes the object name in nameColumn, qualified with the
+// named schema in schemaColumn when it is not in the default schema
+func spannerQualifiedName(schemaColumn, nameColumn string) string {
+	return "CASE WHEN " + schemaColumn + " = '' THEN CONCAT('`', " + nameColumn + ", '`') " +
+		"ELSE CONCAT('`', " + schemaColumn + ", '`.`', " + nameColumn + ", '`') END"
+}
 
-	iter := s.client.Single().Query(ctx, spanner.NewStatement(query))
-	defer iter.Stop()
+// spannerObjectName returns a SQL expression for the unquoted name in nameColumn, qualified with the named schema in
+// schemaColumn when it is not in the default schema. It matches [spannerTable.qualifiedName].
+func spannerObjectName(schemaColumn, nameColumn string) string {
+	return "IF(" + schemaColumn + " = '', " + nameColumn + ", CONCAT(" + schemaColumn + ", '.', " + nameColumn + "))"
+}
 
-	var stmts []string
-	for {
-		row, err := iter.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
-		}
+// spannerChangeStreamTables returns a SQL expression for the names of the tables watched by the change stream in
+// schemaColumn and nameColumn, as returned by [spannerObjectName]. A change stream watching all tables has none.
+func spannerChangeStreamTables(schemaColumn, nameColumn string) string {
+	return `ARRAY(
+				SELECT ` + spannerObjectName("cst.table_schema", "cst.table_name") + `
+				FROM information_schema.change_stream_tables cst
+				WHERE cst.change_stream_schema = ` + schemaColumn + ` AND cst.change_stream_name = ` + nameColumn + `
+			)`
+}
 
-		var stmt string
-		if err := row.Columns(&stmt); err != nil {
-			return nil, errors.Wrap(err, "spanner.Row.Columns()")
+// spannerDropStatements runs a query returning the ddl of each statement and the objects it belongs to
+func spannerDropStatements(ctx context.Context, client *spanner.Client, query string) ([]dropStatement, error) {
+	var stmts []dropStatement
+	if err := spannerQueryRows(ctx, client, query, func(row *spanner.Row) error {
+		var stmt dropStatement
+		if err := row.Columns(&stmt.SQL, &stmt.Objects); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
 		}
 		stmts = append(stmts, stmt)
+
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return stmts, nil
 }
 
-func (s *SpannerMigrator) foreignKeyDropStatements(ctx context.Context) ([]string, error) {
+// grantRevokeStatements revokes every privilege and role membership granted to a database role, so the objects
+// and roles they refer to can be dropped. Column privileges already covered by a table privilege are skipped.
+// Grants on a change stream and on its READ_ table function belong to the tables the change stream watches.
+func (s *SpannerMigrator) grantRevokeStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('REVOKE ', tp.privilege_type, ' ON ',
+			CASE WHEN t.table_type = 'VIEW' THEN 'VIEW ' ELSE 'TABLE ' END, ` + spannerQualifiedName("tp.table_schema", "tp.table_name") + `,
+			' FROM ROLE ` + "`" + `', tp.grantee, '` + "`" + `') AS ddl,
+			[` + spannerObjectName("tp.table_schema", "tp.table_name") + `] AS objects
+		FROM information_schema.table_privileges tp
+		JOIN information_schema.tables t ON t.table_schema = tp.table_schema AND t.table_name = tp.table_name
+		WHERE NOT tp.table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+		UNION ALL
+		SELECT CONCAT('REVOKE ', cp.privilege_type, '(` + "`" + `', cp.column_name, '` + "`" + `) ON TABLE ', ` + spannerQualifiedName("cp.table_schema", "cp.table_name") + `,
+			' FROM ROLE ` + "`" + `', cp.grantee, '` + "`" + `') AS ddl,
+			[` + spannerObjectName("cp.table_schema", "cp.table_name") + `] AS objects
+		FROM information_schema.column_privileges cp
+		WHERE NOT cp.table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+			AND NOT EXISTS (
+				SELECT 1 FROM information_schema.table_privileges tp
+				WHERE tp.table_schema = cp.table_schema AND tp.table_name = cp.table_name
+					AND tp.grantee = cp.grantee AND tp.privilege_type = cp.privilege_type
+			)
+		UNION ALL
+		SELECT CONCAT('REVOKE ', csp.privilege_type, ' ON CHANGE STREAM ', ` + spannerQualifiedName("csp.change_stream_schema", "csp.change_stream_name") + `,
+			' FROM ROLE ` + "`" + `', csp.grantee, '` + "`" + `') AS ddl,
+			` + spannerChangeStreamTables("csp.change_stream_schema", "csp.change_stream_name") + ` AS objects
+		FROM information_schema.change_stream_privileges csp
+		UNION ALL
+		SELECT CONCAT('REVOKE ', rp.privilege_type, ' ON TABLE FUNCTION ', ` + spannerQualifiedName("rp.specific_schema", "rp.specific_name") + `,
+			' FROM ROLE ` + "`" + `', rp.grantee, '` + "`" + `') AS ddl,
+			` + spannerChangeStreamTables("rp.specific_schema", "SUBSTR(rp.specific_name, 6)") + ` AS objects
+		FROM information_schema.routine_privileges rp
+		WHERE NOT rp.specific_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+		UNION ALL
+		SELECT CONCAT('REVOKE ROLE ` + "`" + `', rg.role_name, '` + "`" + ` FROM ROLE ` + "`" + `', rg.grantee, '` + "`" + `') AS ddl,
+			ARRAY<STRING>[] AS objects
+		FROM information_schema.role_grantees rg
+		JOIN information_schema.roles r ON r.role_name = rg.role_name
+		JOIN information_schema.roles g ON g.role_name = rg.grantee
+		WHERE NOT r.is_system AND NOT g.is_system
+		ORDER BY ddl`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+func (s *SpannerMigrator) roleDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP ROLE ` + "`" + `', role_name, '` + "`" + `') AS ddl, ARRAY<STRING>[] AS objects
+		FROM information_schema.roles
+		WHERE NOT is_system
+		ORDER BY role_name`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+// changeStreamDropStatements belong to the tables the change stream watches
+func (s *SpannerMigrator) changeStreamDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP CHANGE STREAM ', ` + spannerQualifiedName("cs.change_stream_schema", "cs.change_stream_name") + `) AS ddl,
+			` + spannerChangeStreamTables("cs.change_stream_schema", "cs.change_stream_name") + ` AS objects
+		FROM information_schema.change_streams cs
+		ORDER BY cs.change_stream_schema, cs.change_stream_name`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+func (s *SpannerMigrator) viewDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP VIEW ', ` + spannerQualifiedName("table_schema", "table_name") + `) AS ddl,
+			[` + spannerObjectName("table_schema", "table_name") + `] AS objects
+		FROM information_schema.tables
+		WHERE NOT TABLE_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+		  AND TABLE_TYPE = 'VIEW'
+		ORDER BY TABLE_SCHEMA, TABLE_NAME`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+// propertyGraphDropStatements belong to the node and edge tables of the graph
+func (s *SpannerMigrator) propertyGraphDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP PROPERTY GRAPH ', ` + spannerQualifiedName("pg.property_graph_schema", "pg.property_graph_name") + `) AS ddl,
+			ARRAY(
+				SELECT ` + spannerObjectName("COALESCE(JSON_VALUE(t, '$.baseSchemaName'), '')", "JSON_VALUE(t, '$.baseTableName')") + `
+				FROM UNNEST(ARRAY_CONCAT(
+					COALESCE(JSON_QUERY_ARRAY(pg.property_graph_metadata_json, '$.nodeTables'), ARRAY<JSON>[]),
+					COALESCE(JSON_QUERY_ARRAY(pg.property_graph_metadata_json, '$.edgeTables'), ARRAY<JSON>[])
+				)) AS t
+			) AS objects
+		FROM information_schema.property_graphs pg
+		ORDER BY pg.property_graph_schema, pg.property_graph_name`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+func (s *SpannerMigrator) modelDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP MODEL ', ` + spannerQualifiedName("model_schema", "model_name") + `) AS ddl,
+			ARRAY<STRING>[] AS objects
+		FROM information_schema.models
+		ORDER BY model_schema, model_name`
+
+	return spannerDropStatements(ctx, s.client, query)
+}
+
+// foreignKeyDropStatements belong to both the constrained table and the referenced table
+func (s *SpannerMigrator) foreignKeyDropStatements(ctx context.Context) ([]dropStatement, error) {
 	query := `
 		SELECT CONCAT(
 			'ALTER TABLE ',
-			CASE
-				WHEN tc.table_schema = '' THEN CONCAT('` + "`" + `', tc.table_name, '` + "`" + `')
-				ELSE CONCAT('` + "`" + `', tc.table_schema, '` + "`.`" + `', tc.table_name, '` + "`" + `')
-			END,
+			` + spannerQualifiedName("tc.table_schema", "tc.table_name") + `,
 			' DROP CONSTRAINT ` + "`" + `', tc.constraint_name, '` + "`" + `'
-		) AS ddl
+		) AS ddl,
+		ARRAY_CONCAT(
+			[` + spannerObjectName("tc.table_schema", "tc.table_name") + `],
+			ARRAY(
+				SELECT ` + spannerObjectName("ref.table_schema", "ref.table_name") + `
+				FROM information_schema.constraint_table_usage ref
+				WHERE ref.constraint_schema = tc.constraint_schema AND ref.constraint_name = tc.constraint_name
+			)
+		) AS objects
 		FROM information_schema.table_constraints tc
 		WHERE tc.constraint_type = 'FOREIGN KEY'
 			AND NOT CONSTRAINT_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
 		ORDER BY tc.table_schema, tc.table_name, tc.constraint_name`
 
-	iter := s.client.Single().Query(ctx, spanner.NewStatement(query))
-	defer iter.Stop()
-
-	var stmts []string
-	for {
-		row, err := iter.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
-		}
-
-		var stmt string
-		if err := row.Columns(&stmt); err != nil {
-			return nil, errors.Wrap(err, "spanner.Row.Columns()")
-		}
-		stmts = append(stmts, stmt)
-	}
-
-	return stmts, nil
+	return spannerDropStatements(ctx, s.client, query)
 }
 
 // NOTE(zredinger): As of 1/26 spanner emulator sets the index_type to 'INDEX' vs using 'SEARCH'.
-func (s *SpannerMigrator) searchIndexDropStatements(ctx context.Context) ([]string, error) {
+func (s *SpannerMigrator) searchIndexDropStatements(ctx context.Context) ([]dropStatement, error) {
 	query := `
-		SELECT CONCAT('DROP SEARCH INDEX ` + "`" + `', idx.index_name, '` + "`" + `') AS ddl
+		SELECT CONCAT('DROP SEARCH INDEX ', ` + spannerQualifiedName("idx.table_schema", "idx.index_name") + `) AS ddl,
+			[` + spannerObjectName("idx.table_schema", "idx.table_name") + `] AS objects
 		FROM information_schema.indexes idx
 		WHERE idx.index_type = 'SEARCH'
 			AND NOT TABLE_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
 		ORDER BY idx.table_schema, idx.table_name, idx.index_name`
 
-	iter := s.client.Single().Query(ctx, spanner.NewStatement(query))
-	defer iter.Stop()
-
-	var stmts []string
-	for {
-		row, err := iter.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
-		}
-
-		var stmt string
-		if err := row.Columns(&stmt); err != nil {
-			return nil, errors.Wrap(err, "spanner.Row.Columns()")
-		}
-		stmts = append(stmts, stmt)
-	}
-
-	return stmts, nil
+	return spannerDropStatements(ctx, s.client, query)
 }
 
-func (s *SpannerMigrator) indexDropStatements(ctx context.Context) ([]string, error) {
+func (s *SpannerMigrator) indexDropStatements(ctx context.Context) ([]dropStatement, error) {
 	query := `
-		SELECT CONCAT('DROP INDEX IF EXISTS ` + "`" + `', idx.index_name, '` + "`" + `') AS ddl
+		SELECT CONCAT('DROP INDEX IF EXISTS ', ` + spannerQualifiedName("idx.table_schema", "idx.index_name") + `) AS ddl,
+			[` + spannerObjectName("idx.table_schema", "idx.table_name") + `] AS objects
 		FROM information_schema.indexes idx
 		WHERE idx.index_type = 'INDEX'
 			AND NOT TABLE_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
 		ORDER BY idx.table_schema, idx.table_name, idx.index_name`
 
-	iter := s.client.Single().Query(ctx, spanner.NewStatement(query))
-	defer iter.Stop()
+	return spannerDropStatements(ctx, s.client, query)
+}
 
-	var stmts []string
-	for {
-		row, err := iter.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
-		}
+// tableDropStatements drops interleaved children before their parents and, unless the references have a cycle,
+// referencing tables before the tables their foreign keys reference
+func (s *SpannerMigrator) tableDropStatements(ctx context.Context) ([]dropStatement, error) {
+	tables, err := spannerTableDependencies(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := sortTableDrops(tables)
+	if err != nil {
+		return nil, err
+	}
 
-		var stmt string
-		if err := row.Columns(&stmt); err != nil {
-			return nil, &database.Error{OrigErr: err}
+	stmts := make([]dropStatement, 0, len(sorted))
+	for _, t := range sorted {
+		stmt := dropStatement{Objects: []string{t.qualifiedName()}}
+		if t.Schema == "" {
+			stmt.SQL = "DROP TABLE `" + t.Name + "`"
+		} else {
+			stmt.SQL = "DROP TABLE `" + t.Schema + "`.`" + t.Name + "`"
 		}
 		stmts = append(stmts, stmt)
 	}
@@ -330,56 +809,23 @@ func (s *SpannerMigrator) indexDropStatements(ctx context.Context) ([]string, er
 	return stmts, nil
 }
 
-func (s *SpannerMigrator) tableDropStatements(ctx context.Context) ([]string, error) {
+func (s *SpannerMigrator) sequenceDropStatements(ctx context.Context) ([]dropStatement, error) {
 	query := `
-		WITH t AS (
-			SELECT table_name, parent_table_name
-			FROM information_schema.tables
-			WHERE NOT TABLE_SCHEMA IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
-			  AND table_type = 'BASE TABLE'
-		),
-		d AS (
-			SELECT
-				c.table_name,
-				CAST(p1.table_name IS NOT NULL AS INT64) +
-				CAST(p2.table_name IS NOT NULL AS INT64) +
-				CAST(p3.table_name IS NOT NULL AS INT64) +
-				CAST(p4.table_name IS NOT NULL AS INT64) +
-				CAST(p5.table_name IS NOT NULL AS INT64) +
-				CAST(p6.table_name IS NOT NULL AS INT64) +
-				CAST(p7.table_name IS NOT NULL AS INT64) AS depth
-			FROM t c
-			LEFT JOIN t p1 ON c.parent_table_name = p1.table_name
-			LEFT JOIN t p2 ON p1.parent_table_name = p2.table_name
-			LEFT JOIN t p3 ON p2.parent_table_name = p3.table_name
-			LEFT JOIN t p4 ON p3.parent_table_name = p4.table_name
-			LEFT JOIN t p5 ON p4.parent_table_name = p5.table_name
-			LEFT JOIN t p6 ON p5.parent_table_name = p6.table_name
-			LEFT JOIN t p7 ON p6.parent_table_name = p7.table_name
-		)
-		SELECT CONCAT('DROP TABLE ` + "`" + `', table_name, '` + "`" + `') AS ddl
-		FROM d
-		ORDER BY depth DESC, table_name`
-
-	iter := s.client.Single().Query(ctx, spanner.NewStatement(query))
-	defer iter.Stop()
-
-	var stmts []string
-	for {
-		row, err := iter.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, errors.Wrap(err, "spanner.RowIterator.Next()")
-		}
+		SELECT CONCAT('DROP SEQUENCE ', ` + spannerQualifiedName("seq.schema", "seq.name") + `) AS ddl,
+			ARRAY<STRING>[] AS objects
+		FROM information_schema.sequences seq
+		WHERE NOT seq.schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+		ORDER BY seq.schema, seq.name`
 
-		var stmt string
-		if err := row.Columns(&stmt); err != nil {
-			return nil, errors.Wrap(err, "spanner.Row.Columns()")
-		}
-		stmts = append(stmts, stmt)
-	}
+	return spannerDropStatements(ctx, s.client, query)
+}
 
-	return stmts, nil
+func (s *SpannerMigrator) schemaDropStatements(ctx context.Context) ([]dropStatement, error) {
+	query := `
+		SELECT CONCAT('DROP SCHEMA ` + "`" + `', schema_name, '` + "`" + `') AS ddl, ARRAY<STRING>[] AS objects
+		FROM information_schema.schemata
+		WHERE NOT schema_name IN('', 'INFORMATION_SCHEMA', 'SPANNER_SYS')
+		ORDER BY schema_name`
+
+	return spannerDropStatements(ctx, s.client, query)
 }
@@ -0,0 +1,68 @@
+package dbinitiator
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// SpannerMigrationFunc is a data migration written in Go, for changes that cannot be expressed in SQL
+type SpannerMigrationFunc func(ctx context.Context, client *spanner.Client) error
+
+type spannerGoMigration struct {
+	identifier string
+	fn         SpannerMigrationFunc
+}
+
+// WithDataMigrationFunc registers fn as the data migration for version.
+//
+// Go migrations run in version order with the files of the data migrations source and are tracked in the
+// data migrations table. They have no down migration. The version must not also be used by a migration file.
+func (s *SpannerMigrator) WithDataMigrationFunc(version uint, identifier string, fn SpannerMigrationFunc) *SpannerMigrator {
+	if s.dataMigrationFuncs == nil {
+		s.dataMigrationFuncs = make(map[uint]spannerGoMigration)
+	}
+	s.dataMigrationFuncs[version] = spannerGoMigration{identifier: identifier, fn: fn}
+
+	return s
+}
+
+// hasGoMigrations reports whether Go migrations are registered for migrationsTable
+func (s *SpannerMigrator) hasGoMigrations(migrationsTable string) bool {
+	return migrationsTable == s.dataMigrationsTable && len(s.dataMigrationFuncs) > 0
+}
+
+// openSource opens the migrations at sourceURL, merging in the Go migrations registered for migrationsTable
+func (s *SpannerMigrator) openSource(migrationsTable, sourceURL string) (source.Driver, error) {
+	src, err := openSource(s.sourceFS, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if !s.hasGoMigrations(migrationsTable) {
+		return src, nil
+	}
+
+	identifiers := make(map[uint]string, len(s.dataMigrationFuncs))
+	for version, m := range s.dataMigrationFuncs {
+		identifiers[version] = m.identifier
+	}
+
+	return newGoMigrationSource(src, identifiers)
+}
+
+func (s *SpannerMigrator) runGoMigration(ctx context.Context, version uint) error {
+	m, ok := s.dataMigrationFuncs[version]
+	if !ok {
+		return errors.Newf("no Go migration registered for version %d", version)
+	}
+
+	ccclogger.FromCtx(ctx).Infof("Running Go data migration %d_%s", version, m.identifier)
+	if err := m.fn(ctx, s.client); err != nil {
+		return errors.Wrapf(err, "SpannerMigrationFunc(): %d_%s", version, m.identifier)
+	}
+
+	return nil
+}
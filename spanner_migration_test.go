@@ -5,8 +5,12 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"cloud.google.com/go/spanner"
 	"github.com/go-playground/errors/v5"
@@ -467,6 +471,95 @@ func TestSpannerMigrator_MigrateDropSchema(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "drop schema with change streams, sequences, named schemas, roles, models and property graphs",
+			args: args{
+				schemaSourceURL: "file://testdata/spanner/migrations_extended",
+			},
+			wantErr: false,
+			preAssertions: []assertion{
+				{
+					name:  "OrdersStream change stream should exist before drop",
+					query: `SELECT EXISTS(SELECT 1 FROM information_schema.change_streams WHERE change_stream_name = 'OrdersStream')`,
+				},
+				{
+					name:  "Analyst role should exist before drop",
+					query: `SELECT EXISTS(SELECT 1 FROM information_schema.roles WHERE role_name = 'Analyst')`,
+				},
+				{
+					name:  "Sales schema should exist before drop",
+					query: `SELECT EXISTS(SELECT 1 FROM information_schema.schemata WHERE schema_name = 'Sales')`,
+				},
+			},
+			postAssertions: []assertion{
+				{
+					name:  "No tables should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE NOT table_schema IN ('INFORMATION_SCHEMA', 'SPANNER_SYS') AND table_type = 'BASE TABLE')`,
+				},
+				{
+					name:  "No change streams should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.change_streams)`,
+				},
+				{
+					name:  "No sequences should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.sequences)`,
+				},
+				{
+					name:  "No user roles should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.roles WHERE NOT is_system)`,
+				},
+				{
+					name:  "No property graphs should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.property_graphs)`,
+				},
+				{
+					name:  "No models should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.models)`,
+				},
+				{
+					name:  "No named schemas should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.schemata WHERE NOT schema_name IN ('', 'INFORMATION_SCHEMA', 'SPANNER_SYS'))`,
+				},
+			},
+		},
+		{
+			name: "drop schema with deep interleave hierarchy",
+			args: args{
+				schemaSourceURL: "file://testdata/spanner/migrations_deep_interleave",
+			},
+			wantErr: false,
+			preAssertions: []assertion{
+				{
+					name:  "Level8 table should exist before drop",
+					query: `SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name = 'Level8' AND parent_table_name = 'Level7')`,
+				},
+			},
+			postAssertions: []assertion{
+				{
+					name:  "No user tables should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = '' AND table_type = 'BASE TABLE')`,
+				},
+			},
+		},
+		{
+			name: "drop schema with foreign keys referencing each other",
+			args: args{
+				schemaSourceURL: "file://testdata/spanner/migrations_fk_cycle",
+			},
+			wantErr: false,
+			preAssertions: []assertion{
+				{
+					name:  "Foreign keys should reference each other before drop",
+					query: `SELECT COUNT(*) = 2 FROM information_schema.table_constraints WHERE constraint_type = 'FOREIGN KEY' AND table_name IN ('Accounts', 'Owners')`,
+				},
+			},
+			postAssertions: []assertion{
+				{
+					name:  "No user tables should exist after drop",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = '' AND table_type = 'BASE TABLE')`,
+				},
+			},
+		},
 		{
 			name: "drop schema on empty database",
 			args: args{
@@ -542,6 +635,216 @@ func TestSpannerMigrator_MigrateDropSchema(t *testing.T) {
 	}
 }
 
+func TestSpannerMigrator_MigrateToAndSteps(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	const sourceURL = "file://testdata/spanner/migrations_versioned"
+
+	type assertion struct {
+		name  string
+		query string
+	}
+	tests := []struct {
+		name        string
+		migrate     func(svc *SpannerMigrator) error
+		wantErr     bool
+		wantVersion uint
+		assertions  []assertion
+	}{
+		{
+			name: "migrate up to version 2",
+			migrate: func(svc *SpannerMigrator) error {
+				return svc.MigrateSchemaTo(ctx, sourceURL, 2)
+			},
+			wantVersion: 2,
+			assertions: []assertion{
+				{
+					name:  "Email column should exist",
+					query: `SELECT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = 'Accounts' AND column_name = 'Email')`,
+				},
+				{
+					name:  "Accounts_Email index should not exist",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.indexes WHERE index_name = 'Accounts_Email')`,
+				},
+			},
+		},
+		{
+			name: "migrate up then down to version 1",
+			migrate: func(svc *SpannerMigrator) error {
+				if err := svc.MigrateUpSchema(ctx, sourceURL); err != nil {
+					return err
+				}
+
+				return svc.MigrateSchemaTo(ctx, sourceURL, 1)
+			},
+			wantVersion: 1,
+			assertions: []assertion{
+				{
+					name:  "Email column should not exist",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = 'Accounts' AND column_name = 'Email')`,
+				},
+			},
+		},
+		{
+			name: "step up all then step down one",
+			migrate: func(svc *SpannerMigrator) error {
+				if err := svc.MigrateSchemaSteps(ctx, sourceURL, 3); err != nil {
+					return err
+				}
+
+				return svc.MigrateSchemaSteps(ctx, sourceURL, -1)
+			},
+			wantVersion: 2,
+			assertions: []assertion{
+				{
+					name:  "Accounts_Email index should not exist",
+					query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.indexes WHERE index_name = 'Accounts_Email')`,
+				},
+			},
+		},
+		{
+			name: "migrate to version that does not exist",
+			migrate: func(svc *SpannerMigrator) error {
+				return svc.MigrateSchemaTo(ctx, sourceURL, 9)
+			},
+			wantErr: true,
+		},
+		{
+			name: "step past the last migration",
+			migrate: func(svc *SpannerMigrator) error {
+				return svc.MigrateSchemaSteps(ctx, sourceURL, 4)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dbName := genDBName()
+			db, err := container.CreateDatabase(ctx, dbName)
+			if err != nil {
+				t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+			}
+			defer func() {
+				if err := db.DropDatabase(context.Background()); err != nil {
+					t.Errorf("DB.DropDatabase() err=%s", err)
+				}
+				if err := db.Close(); err != nil {
+					t.Errorf("DB.Close() err=%s", err)
+				}
+			}()
+
+			svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+			if err != nil {
+				t.Fatalf("NewSpannerMigrator() error = %v", err)
+			}
+			defer func() {
+				if err := svc.Close(); err != nil {
+					t.Errorf("SpannerMigrator.Close() err=%s", err)
+				}
+			}()
+
+			if err := tt.migrate(svc); (err != nil) != tt.wantErr {
+				t.Fatalf("migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			status, err := svc.SchemaStatus(ctx, sourceURL)
+			if err != nil {
+				t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+			}
+			if !status.HasVersion || status.Version != tt.wantVersion {
+				t.Errorf("SpannerMigrator.SchemaStatus() = %s, want version %d", status, tt.wantVersion)
+			}
+
+			for _, a := range tt.assertions {
+				if result, err := assertionQuery(ctx, db.Client, a.query); err != nil {
+					t.Fatalf("Assertion %q failed to execute: %v", a.name, err)
+				} else if !result {
+					t.Errorf("Assertion %q returned false", a.name)
+				}
+			}
+		})
+	}
+}
+
+func TestSpannerMigrator_Status(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+	svc.WithChecksumPolicy(ChecksumFail).WithAuditLog()
+
+	schemaSourceURL := "file://testdata/spanner/migrations_versioned"
+	status, err := svc.SchemaStatus(ctx, schemaSourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+	}
+	if status.HasVersion || len(status.Pending) != 3 {
+		t.Errorf("SpannerMigrator.SchemaStatus() before migration = %s, want no version and 3 pending", status)
+	}
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name IN ('SchemaMigrationsHistory', 'SchemaMigrationsAudit'))`); err != nil || !ok {
+		t.Errorf("history and audit tables should not be created by SpannerMigrator.SchemaStatus(), got %v, err=%v", ok, err)
+	}
+
+	if err := svc.MigrateUpSchema(ctx, schemaSourceURL); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	status, err = svc.SchemaStatus(ctx, schemaSourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+	}
+	if !status.HasVersion || status.Version != 3 || !status.UpToDate() {
+		t.Errorf("SpannerMigrator.SchemaStatus() after migration = %s, want version 3 and up to date", status)
+	}
+
+	status, err = svc.DataStatus(ctx, "file://testdata/spanner/datamigrations")
+	if err != nil {
+		t.Fatalf("SpannerMigrator.DataStatus() error = %v", err)
+	}
+	if status.HasVersion || len(status.Pending) != 1 || status.MigrationsTable != "DataMigrations" {
+		t.Errorf("SpannerMigrator.DataStatus() = %s, want no version and 1 pending", status)
+	}
+}
+
 func genDBName() string {
 	var randStr strings.Builder
 
@@ -574,3 +877,397 @@ func assertionQuery(ctx context.Context, client *spanner.Client, query string) (
 
 	return result, nil
 }
+
+func TestSpannerMigrator_SourceFS(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+	svc.WithSourceFS(os.DirFS("testdata/spanner"))
+
+	if err := svc.MigrateUpSchema(ctx, "migrations_versioned"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	status, err := svc.SchemaStatus(ctx, "migrations_versioned")
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+	}
+	if !status.HasVersion || status.Version != 3 || !status.UpToDate() {
+		t.Errorf("SpannerMigrator.SchemaStatus() = %s, want version 3 and up to date", status)
+	}
+
+	if err := svc.MigrateUpSchema(ctx, "migrations_does_not_exist"); err == nil {
+		t.Errorf("SpannerMigrator.MigrateUpSchema() error = nil, want error for missing directory")
+	}
+}
+
+func TestSpannerMigrator_MigrateUpDataFunc(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	svc.WithDataMigrationFunc(2, "rename_computers", func(ctx context.Context, client *spanner.Client) error {
+		_, err := client.Apply(ctx, []*spanner.Mutation{
+			spanner.Update("Categories", []string{"Id", "Name"}, []any{"cat-002", "Laptops"}),
+		})
+
+		return err
+	})
+
+	if err := svc.MigrateUpSchema(ctx, "file://testdata/spanner/migrations_full"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	dataSourceURL := "file://testdata/spanner/datamigrations_full"
+	status, err := svc.DataStatus(ctx, dataSourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.DataStatus() error = %v", err)
+	}
+	if len(status.Pending) != 2 || status.Pending[1].Identifier != "rename_computers" {
+		t.Errorf("SpannerMigrator.DataStatus() = %s, want the file and the Go migration pending", status)
+	}
+
+	if err := svc.MigrateUpData(ctx, dataSourceURL); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpData() error = %v", err)
+	}
+
+	for _, query := range []string{
+		`SELECT EXISTS(SELECT 1 FROM Categories WHERE Id = 'cat-002' AND Name = 'Laptops')`,
+		`SELECT EXISTS(SELECT 1 FROM DataMigrations WHERE Version = 2 AND NOT Dirty)`,
+		`SELECT EXISTS(SELECT 1 FROM SchemaMigrations WHERE Version = 1 AND NOT Dirty)`,
+	} {
+		if ok, err := assertionQuery(ctx, db.Client, query); err != nil || !ok {
+			t.Errorf("assertionQuery(%q) = %v, err=%v", query, ok, err)
+		}
+	}
+}
+
+func TestSpannerMigrator_ChecksumPolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	fsys := fstest.MapFS{
+		"migrations/000001_create_widgets.up.sql": {Data: []byte("CREATE TABLE Widgets (Id STRING(36) NOT NULL) PRIMARY KEY (Id);")},
+	}
+	svc.WithSourceFS(fsys).WithChecksumPolicy(ChecksumFail)
+
+	if err := svc.MigrateUpSchema(ctx, "migrations"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	fsys["migrations/000001_create_widgets.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE Widgets (Id STRING(64) NOT NULL) PRIMARY KEY (Id);")}
+	fsys["migrations/000002_create_gadgets.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE Gadgets (Id STRING(36) NOT NULL) PRIMARY KEY (Id);")}
+
+	var mismatchErr *ChecksumMismatchError
+	if err := svc.MigrateUpSchema(ctx, "migrations"); !errors.As(err, &mismatchErr) {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v, want *ChecksumMismatchError", err)
+	}
+	if len(mismatchErr.Mismatches) != 1 || mismatchErr.Mismatches[0].Version != 1 {
+		t.Errorf("ChecksumMismatchError.Mismatches = %v, want version 1", mismatchErr.Mismatches)
+	}
+
+	svc.WithChecksumPolicy(ChecksumWarn)
+	if err := svc.MigrateUpSchema(ctx, "migrations"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	status, err := svc.SchemaStatus(ctx, "migrations")
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaStatus() error = %v", err)
+	}
+	if status.Version != 2 || !status.UpToDate() {
+		t.Errorf("SpannerMigrator.SchemaStatus() = %s, want version 2 and up to date", status)
+	}
+}
+
+func TestSpannerMigrator_AuditLog(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+	svc.WithAuditLog().WithOperator("deployer@ci")
+
+	if err := svc.MigrateUpSchema(ctx, "file://testdata/spanner/migration_error"); err == nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = nil, want error")
+	}
+
+	records, err := svc.SchemaAuditLog(ctx)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.SchemaAuditLog() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("SpannerMigrator.SchemaAuditLog() returned %d record(s), want 1", len(records))
+	}
+	if rec := records[0]; rec.Version != 1 || rec.Identifier != "users" || rec.Direction != MigrationUp ||
+		rec.Outcome != MigrationFailed || rec.Error == "" || rec.Operator != "deployer@ci" {
+		t.Errorf("SpannerMigrator.SchemaAuditLog() = %+v, want failed up migration 1_users by deployer@ci", rec)
+	}
+}
+
+func TestSpannerMigrator_MigrateDropSchemaFilter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	if err := svc.MigrateUpSchema(ctx, "file://testdata/spanner/migrations_full"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	stmts, err := svc.WithDropFilter(DropFilter{Include: []string{"Categ*"}}).DropSchemaStatements(ctx)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.DropSchemaStatements() error = %v", err)
+	}
+	if want := []string{"DROP INDEX IF EXISTS `Categories_Name`", "DROP TABLE `Categories`"}; !reflect.DeepEqual(stmts, want) {
+		t.Errorf("SpannerMigrator.DropSchemaStatements() = %v, want %v", stmts, want)
+	}
+
+	if err := svc.WithDropFilter(DropFilter{Exclude: []string{"Products", "*Migrations"}}).MigrateDropSchema(ctx); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateDropSchema() error = %v", err)
+	}
+
+	for _, a := range []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "Orders, Categories and OrderSummary should be dropped",
+			query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name IN ('Orders', 'Categories', 'OrderSummary'))`,
+		},
+		{
+			name:  "Products and its search index should be kept",
+			query: `SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name = 'Products') AND EXISTS(SELECT 1 FROM information_schema.indexes WHERE index_name = 'ProductsSearchIndex')`,
+		},
+		{
+			name:  "SchemaMigrations should be kept",
+			query: `SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name = 'SchemaMigrations')`,
+		},
+	} {
+		if ok, err := assertionQuery(ctx, db.Client, a.query); err != nil || !ok {
+			t.Errorf("%s, got %v, err=%v", a.name, ok, err)
+		}
+	}
+}
+
+func TestSpannerMigrator_MigrateDropSchemaFilterExtended(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	if err := svc.MigrateUpSchema(ctx, "file://testdata/spanner/migrations_extended"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	svc.WithDropFilter(DropFilter{Include: []string{"Orders"}})
+
+	stmts, err := svc.DropSchemaStatements(ctx)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.DropSchemaStatements() error = %v", err)
+	}
+	for _, want := range []string{
+		"REVOKE SELECT ON CHANGE STREAM `OrdersStream` FROM ROLE `Analyst`",
+		"REVOKE EXECUTE ON TABLE FUNCTION `READ_OrdersStream` FROM ROLE `Analyst`",
+		"DROP CHANGE STREAM `OrdersStream`",
+		"DROP PROPERTY GRAPH `OrdersGraph`",
+		"DROP TABLE `Orders`",
+	} {
+		if !slices.Contains(stmts, want) {
+			t.Errorf("SpannerMigrator.DropSchemaStatements() = %v, want it to contain %q", stmts, want)
+		}
+	}
+
+	if err := svc.MigrateDropSchema(ctx); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateDropSchema() error = %v", err)
+	}
+
+	for _, a := range []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "Orders, OrdersStream and OrdersGraph should be dropped",
+			query: `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name = 'Orders') AND NOT EXISTS(SELECT 1 FROM information_schema.change_streams) AND NOT EXISTS(SELECT 1 FROM information_schema.property_graphs)`,
+		},
+		{
+			name:  "Sales.Invoices, OrderIds and the roles should be kept",
+			query: `SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = 'Sales' AND table_name = 'Invoices') AND EXISTS(SELECT 1 FROM information_schema.sequences WHERE name = 'OrderIds') AND EXISTS(SELECT 1 FROM information_schema.roles WHERE role_name = 'Analyst')`,
+		},
+	} {
+		if ok, err := assertionQuery(ctx, db.Client, a.query); err != nil || !ok {
+			t.Errorf("%s, got %v, err=%v", a.name, ok, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package dbinitiator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	ccclogger "github.com/cccteam/logger"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// PartitionedDMLDirective marks a data migration file to be run as Partitioned DML.
+// It must appear in the comments at the top of the file, before the first statement.
+//
+// Each statement of the file is run with [spanner.Client.PartitionedUpdate], which is not subject to the
+// transaction mutation limit. Partitioned DML statements must be idempotent and cannot be INSERT statements.
+const PartitionedDMLDirective = "-- dbinitiator:partitioned-dml"
+
+// partitionedDMLDriver runs data migration files marked with PartitionedDMLDirective as Partitioned DML.
+// All other migrations are passed through to the wrapped driver.
+type partitionedDMLDriver struct {
+	database.Driver
+	ctx    context.Context
+	client *spanner.Client
+}
+
+func (d *partitionedDMLDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return errors.Wrap(err, "io.ReadAll()")
+	}
+
+	if !hasPartitionedDMLDirective(body) {
+		if err := d.Driver.Run(bytes.NewReader(body)); err != nil {
+			return errors.Wrap(err, "database.Driver.Run()")
+		}
+
+		return nil
+	}
+
+	stmts, err := spannerStatements(body)
+	if err != nil {
+		return errors.Wrap(err, "spannerStatements()")
+	}
+
+	for _, stmt := range stmts {
+		if stmt.Type != StatementTypeDML {
+			return &database.Error{Err: "partitioned DML migration contains a DDL statement", Query: []byte(stmt.SQL)}
+		}
+
+		count, err := d.client.PartitionedUpdate(d.ctx, spanner.Statement{SQL: stmt.SQL})
+		if err != nil {
+			return &database.Error{OrigErr: err, Err: "partitioned DML migration failed", Query: []byte(stmt.SQL)}
+		}
+
+		ccclogger.FromCtx(d.ctx).Infof("Partitioned DML affected %d row(s): %s", count, strings.TrimSpace(stmt.SQL))
+	}
+
+	return nil
+}
+
+// hasPartitionedDMLDirective reports whether the comments at the top of migr contain PartitionedDMLDirective
+func hasPartitionedDMLDirective(migr []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(migr))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == PartitionedDMLDirective:
+			return true
+		case line == "" || strings.HasPrefix(line, "--"):
+			continue
+		default:
+			return false
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package dbinitiator
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_hasPartitionedDMLDirective(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		migr string
+		want bool
+	}{
+		{
+			name: "directive on first line",
+			migr: "-- dbinitiator:partitioned-dml\nUPDATE Categories SET Name = UPPER(Name) WHERE TRUE;\n",
+			want: true,
+		},
+		{
+			name: "directive after other comments and blank lines",
+			migr: "-- Backfill names\n\n  -- dbinitiator:partitioned-dml\nUPDATE Categories SET Name = UPPER(Name) WHERE TRUE;\n",
+			want: true,
+		},
+		{
+			name: "directive after first statement",
+			migr: "UPDATE Categories SET Name = UPPER(Name) WHERE TRUE;\n-- dbinitiator:partitioned-dml\n",
+			want: false,
+		},
+		{
+			name: "no directive",
+			migr: "-- partitioned-dml\nUPDATE Categories SET Name = UPPER(Name) WHERE TRUE;\n",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := hasPartitionedDMLDirective([]byte(tt.migr)); got != tt.want {
+				t.Errorf("hasPartitionedDMLDirective() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpannerMigrator_MigrateUpDataPartitionedDML(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	if err := svc.MigrateUpSchema(ctx, "file://testdata/spanner/migrations_full"); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpSchema() error = %v", err)
+	}
+
+	dataSourceURL := "file://testdata/spanner/datamigrations_pdml"
+	plan, err := svc.PlanUpData(ctx, dataSourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.PlanUpData() error = %v", err)
+	}
+	if len(plan.Migrations) != 2 || plan.Migrations[0].PartitionedDML || !plan.Migrations[1].PartitionedDML {
+		t.Errorf("SpannerMigrator.PlanUpData() = %s, want only the second migration as partitioned DML", plan)
+	}
+
+	if err := svc.MigrateUpData(ctx, dataSourceURL); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateUpData() error = %v", err)
+	}
+
+	for _, query := range []string{
+		`SELECT NOT EXISTS(SELECT 1 FROM Categories WHERE Name != UPPER(Name))`,
+		`SELECT EXISTS(SELECT 1 FROM DataMigrations WHERE Version = 2 AND NOT Dirty)`,
+	} {
+		if ok, err := assertionQuery(ctx, db.Client, query); err != nil || !ok {
+			t.Errorf("assertionQuery(%q) = %v, err=%v", query, ok, err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package dbinitiator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ccclogger "github.com/cccteam/logger"
+	"github.com/cloudspannerecosystem/memefish"
+	"github.com/cloudspannerecosystem/memefish/token"
+	"github.com/go-playground/errors/v5"
+)
+
+// StatementType is the kind of a migration statement, which decides how Spanner runs it
+type StatementType string
+
+const (
+	// StatementTypeDDL statements are applied with UpdateDatabaseDdl
+	StatementTypeDDL StatementType = "DDL"
+
+	// StatementTypeDML statements are applied in a read-write transaction
+	StatementTypeDML StatementType = "DML"
+)
+
+// MigrationPlan lists the statements an up migration would run, without running them
+type MigrationPlan struct {
+	// MigrationsTable is the table the track stores its version in
+	MigrationsTable string
+
+	// SourceURL is the migrations source the plan was read from
+	SourceURL string
+
+	// HasVersion is false if no migration has been applied yet
+	HasVersion bool
+
+	// Version is the currently applied version. It is only valid when HasVersion is true.
+	Version uint
+
+	// Migrations are the pending migrations, in the order they will run
+	Migrations []PlannedMigration
+}
+
+// PlannedMigration is a pending migration file split into its statements
+type PlannedMigration struct {
+	Version    uint
+	Identifier string
+	Statements []PlannedStatement
+
+	// GoMigration is true for a registered Go migration function, which has no statements to show
+	GoMigration bool
+
+	// PartitionedDML is true for a data migration file marked with [PartitionedDMLDirective]
+	PartitionedDML bool
+}
+
+// PlannedStatement is a single statement of a migration file
+type PlannedStatement struct {
+	Type StatementType
+	SQL  string
+}
+
+// String renders the plan as text, suitable for CI logs
+func (p *MigrationPlan) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: ", p.MigrationsTable)
+	if p.HasVersion {
+		fmt.Fprintf(&b, "version %d", p.Version)
+	} else {
+		b.WriteString("no migrations applied")
+	}
+
+	if len(p.Migrations) == 0 {
+		b.WriteString(", no pending migrations\n")
+
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ", %d pending migration(s) from %s:\n", len(p.Migrations), p.SourceURL)
+	for _, m := range p.Migrations {
+		fmt.Fprintf(&b, "  %d_%s", m.Version, m.Identifier)
+		if m.PartitionedDML {
+			b.WriteString(" (partitioned DML)")
+		}
+		b.WriteString("\n")
+		switch {
+		case m.GoMigration:
+			b.WriteString("    (Go migration function)\n")
+		case len(m.Statements) == 0:
+			b.WriteString("    (no statements)\n")
+		}
+		for _, stmt := range m.Statements {
+			fmt.Fprintf(&b, "    %s: %s;\n", stmt.Type, strings.ReplaceAll(strings.TrimSpace(stmt.SQL), "\n", "\n      "))
+		}
+	}
+
+	return b.String()
+}
+
+// PlanUpSchema returns the statements MigrateUpSchema would run for the sourceURL, without running them
+func (s *SpannerMigrator) PlanUpSchema(ctx context.Context, sourceURL string) (*MigrationPlan, error) {
+	ccclogger.FromCtx(ctx).Infof("Planning schema migrations from %s", sourceURL)
+	plan, err := s.planUp(ctx, s.schemaMigrationsTable, sourceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.planUp()")
+	}
+
+	return plan, nil
+}
+
+// PlanUpData returns the statements MigrateUpData would run for the sourceURL, without running them
+func (s *SpannerMigrator) PlanUpData(ctx context.Context, sourceURL string) (*MigrationPlan, error) {
+	ccclogger.FromCtx(ctx).Infof("Planning data migrations from %s", sourceURL)
+	plan, err := s.planUp(ctx, s.dataMigrationsTable, sourceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.planUp()")
+	}
+
+	return plan, nil
+}
+
+func (s *SpannerMigrator) planUp(ctx context.Context, migrationsTable, sourceURL string) (*MigrationPlan, error) {
+	status, err := s.status(ctx, migrationsTable, sourceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "SpannerMigrator.status()")
+	}
+	if status.Dirty {
+		return nil, &DirtyError{MigrationsTable: migrationsTable, Version: status.Version}
+	}
+
+	src, err := s.openSource(migrationsTable, sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	plan := &MigrationPlan{
+		MigrationsTable: migrationsTable,
+		SourceURL:       sourceURL,
+		HasVersion:      status.HasVersion,
+		Version:         status.Version,
+	}
+
+	for _, pending := range status.Pending {
+		if _, ok := s.dataMigrationFuncs[pending.Version]; ok && s.hasGoMigrations(migrationsTable) {
+			plan.Migrations = append(plan.Migrations, PlannedMigration{Version: pending.Version, Identifier: pending.Identifier, GoMigration: true})
+
+			continue
+		}
+
+		migr, _, err := readUpMigration(src, pending.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		stmts, err := spannerStatements(migr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "spannerStatements(): version %d", pending.Version)
+		}
+
+		plan.Migrations = append(plan.Migrations, PlannedMigration{
+			Version:        pending.Version,
+			Identifier:     pending.Identifier,
+			Statements:     stmts,
+			PartitionedDML: migrationsTable == s.dataMigrationsTable && hasPartitionedDMLDirective(migr),
+		})
+	}
+
+	return plan, nil
+}
+
+// spannerStatements splits a migration into statements the same way the spanner driver
+// does when CleanStatements is enabled. Statements starting with INSERT, UPDATE or DELETE
+// are DML, all others are DDL.
+func spannerStatements(migr []byte) ([]PlannedStatement, error) {
+	lex := &memefish.Lexer{
+		File: &token.File{Buffer: string(migr)},
+	}
+
+	var stmts []PlannedStatement
+	var stmtType StatementType
+	var stmt strings.Builder
+	for {
+		if err := lex.NextToken(); err != nil {
+			return nil, errors.Wrap(err, "memefish.Lexer.NextToken()")
+		}
+
+		if stmtType == "" {
+			switch {
+			case lex.Token.IsKeywordLike("INSERT") || lex.Token.IsKeywordLike("DELETE") || lex.Token.IsKeywordLike("UPDATE"):
+				stmtType = StatementTypeDML
+			default:
+				stmtType = StatementTypeDDL
+			}
+		}
+
+		if lex.Token.Kind == token.TokenEOF || lex.Token.Kind == ";" {
+			if stmt.Len() > 0 {
+				stmts = append(stmts, PlannedStatement{Type: stmtType, SQL: stmt.String()})
+			}
+			stmtType = ""
+			stmt.Reset()
+
+			if lex.Token.Kind == token.TokenEOF {
+				return stmts, nil
+			}
+
+			continue
+		}
+
+		// a line comment consumes its trailing newline, so it is added back to keep statements readable
+		if len(lex.Token.Comments) > 0 && strings.HasPrefix(lex.Token.Comments[0].Raw, "--") {
+			stmt.WriteString("\n")
+		}
+		if stmt.Len() > 0 {
+			stmt.WriteString(lex.Token.Space)
+		}
+		stmt.WriteString(lex.Token.Raw)
+	}
+}
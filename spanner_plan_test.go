@@ -0,0 +1,160 @@
+package dbinitiator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_spannerStatements(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		migr    string
+		want    []PlannedStatement
+		wantErr bool
+	}{
+		{
+			name: "single DDL statement",
+			migr: "CREATE INDEX Accounts_Email ON Accounts(Email);\n",
+			want: []PlannedStatement{
+				{Type: StatementTypeDDL, SQL: "CREATE INDEX Accounts_Email ON Accounts(Email)"},
+			},
+		},
+		{
+			name: "mixed DDL and DML without trailing semicolon",
+			migr: "ALTER TABLE Accounts ADD COLUMN Email STRING(MAX);\nUPDATE Accounts SET Email = 'a@b.c' WHERE TRUE;\ndelete from Accounts where Email IS NULL",
+			want: []PlannedStatement{
+				{Type: StatementTypeDDL, SQL: "ALTER TABLE Accounts ADD COLUMN Email STRING(MAX)"},
+				{Type: StatementTypeDML, SQL: "UPDATE Accounts SET Email = 'a@b.c' WHERE TRUE"},
+				{Type: StatementTypeDML, SQL: "delete from Accounts where Email IS NULL"},
+			},
+		},
+		{
+			name: "semicolon in string literal",
+			migr: "INSERT INTO Notes (Id, Body) VALUES (1, 'a;b');",
+			want: []PlannedStatement{
+				{Type: StatementTypeDML, SQL: "INSERT INTO Notes (Id, Body) VALUES (1, 'a;b')"},
+			},
+		},
+		{
+			name: "comments only",
+			migr: "-- nothing to do\n",
+			want: nil,
+		},
+		{
+			name:    "unterminated string",
+			migr:    "INSERT INTO Notes (Id, Body) VALUES (1, 'a);",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := spannerStatements([]byte(tt.migr))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("spannerStatements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spannerStatements() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationPlan_String(t *testing.T) {
+	t.Parallel()
+
+	plan := &MigrationPlan{
+		MigrationsTable: "SchemaMigrations",
+		SourceURL:       "file://testdata/spanner/migrations_versioned",
+		HasVersion:      true,
+		Version:         1,
+		Migrations: []PlannedMigration{
+			{
+				Version:    2,
+				Identifier: "add_accounts_email",
+				Statements: []PlannedStatement{{Type: StatementTypeDDL, SQL: "ALTER TABLE Accounts\nADD COLUMN Email STRING(MAX)"}},
+			},
+			{Version: 3, Identifier: "empty"},
+			{Version: 4, Identifier: "backfill", GoMigration: true},
+			{
+				Version:        5,
+				Identifier:     "uppercase_names",
+				Statements:     []PlannedStatement{{Type: StatementTypeDML, SQL: "UPDATE Categories SET Name = UPPER(Name) WHERE TRUE"}},
+				PartitionedDML: true,
+			},
+		},
+	}
+
+	want := "SchemaMigrations: version 1, 4 pending migration(s) from file://testdata/spanner/migrations_versioned:\n" +
+		"  2_add_accounts_email\n" +
+		"    DDL: ALTER TABLE Accounts\n" +
+		"      ADD COLUMN Email STRING(MAX);\n" +
+		"  3_empty\n" +
+		"    (no statements)\n" +
+		"  4_backfill\n" +
+		"    (Go migration function)\n" +
+		"  5_uppercase_names (partitioned DML)\n" +
+		"    DML: UPDATE Categories SET Name = UPPER(Name) WHERE TRUE;\n"
+	if got := plan.String(); got != want {
+		t.Errorf("MigrationPlan.String() = %q, want %q", got, want)
+	}
+}
+
+func TestSpannerMigrator_PlanUpSchema(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("NewSpannerContainer(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	dbName := genDBName()
+	db, err := container.CreateDatabase(ctx, dbName)
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	svc, err := NewSpannerMigrator(ctx, container.projectID, container.instanceID, dbName, container.opts...)
+	if err != nil {
+		t.Fatalf("NewSpannerMigrator() error = %v", err)
+	}
+	defer func() {
+		if err := svc.Close(); err != nil {
+			t.Errorf("SpannerMigrator.Close() err=%s", err)
+		}
+	}()
+
+	sourceURL := "file://testdata/spanner/migrations_versioned"
+	if err := svc.MigrateSchemaTo(ctx, sourceURL, 1); err != nil {
+		t.Fatalf("SpannerMigrator.MigrateSchemaTo() error = %v", err)
+	}
+
+	plan, err := svc.PlanUpSchema(ctx, sourceURL)
+	if err != nil {
+		t.Fatalf("SpannerMigrator.PlanUpSchema() error = %v", err)
+	}
+	if !plan.HasVersion || plan.Version != 1 || len(plan.Migrations) != 2 {
+		t.Fatalf("SpannerMigrator.PlanUpSchema() = %s, want version 1 and 2 pending migrations", plan)
+	}
+	if got := plan.Migrations[0].Statements; len(got) != 1 || got[0].Type != StatementTypeDDL || !strings.HasPrefix(got[0].SQL, "ALTER TABLE Accounts") {
+		t.Errorf("PlannedMigration.Statements = %v, want the ALTER TABLE statement", got)
+	}
+
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT NOT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = 'Accounts' AND column_name = 'Email')`); err != nil || !ok {
+		t.Errorf("PlanUpSchema() must not run migrations, got %v, err=%v", ok, err)
+	}
+}
@@ -0,0 +1,270 @@
+package dbinitiator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/memefish"
+	"github.com/cloudspannerecosystem/memefish/ast"
+	"github.com/go-playground/errors/v5"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+)
+
+// TableDependencyCycleError is returned when tables are interleaved in each other,
+// so there is no order in which they can be dropped
+type TableDependencyCycleError struct {
+	// Tables are the tables in or behind the cycle, qualified with their named schema
+	Tables []string
+}
+
+func (e *TableDependencyCycleError) Error() string {
+	return fmt.Sprintf("tables have a dependency cycle and cannot be dropped in order: %s", strings.Join(e.Tables, ", "))
+}
+
+// spannerTable is a base table and the tables it depends on
+type spannerTable struct {
+	Schema string
+	Name   string
+
+	// Parent is the interleave parent, qualified with its named schema
+	Parent string
+
+	// References are the tables referenced by foreign keys, qualified with their named schema
+	References []string
+}
+
+// qualifiedName returns the table name, qualified with its named schema when it is not in the default schema
+func (t *spannerTable) qualifiedName() string {
+	return qualifiedTableName(t.Schema, t.Name)
+}
+
+func qualifiedTableName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+
+	return schema + "." + name
+}
+
+// spannerTrackingTables returns the migrations tables with the history and audit tables kept alongside them
+func spannerTrackingTables(migrationsTables ...string) []string {
+	var tables []string
+	for _, table := range migrationsTables {
+		tables = append(tables, table, table+"History", table+"Audit")
+	}
+
+	return tables
+}
+
+// spannerTableDependencies returns the base tables in the database with their interleave parents and foreign key references
+func spannerTableDependencies(ctx context.Context, client *spanner.Client) ([]*spannerTable, error) {
+	tablesQuery := `
+		SELECT table_schema, table_name, parent_table_name
+		FROM information_schema.tables
+		WHERE NOT table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+			AND table_type = 'BASE TABLE'`
+
+	var tables []*spannerTable
+	byName := make(map[string]*spannerTable)
+	if err := spannerQueryRows(ctx, client, tablesQuery, func(row *spanner.Row) error {
+		var parent spanner.NullString
+		table := &spannerTable{}
+		if err := row.Columns(&table.Schema, &table.Name, &parent); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		if parent.Valid && parent.StringVal != "" {
+			table.Parent = qualifiedTableName(table.Schema, parent.StringVal)
+		}
+		tables = append(tables, table)
+		byName[table.qualifiedName()] = table
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// constraint_table_usage lists the referenced table of each foreign key
+	referencesQuery := `
+		SELECT tc.table_schema, tc.table_name, ref.table_schema, ref.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_table_usage ref
+			ON ref.constraint_schema = tc.constraint_schema AND ref.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type = 'FOREIGN KEY'
+			AND NOT tc.table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')`
+
+	if err := spannerQueryRows(ctx, client, referencesQuery, func(row *spanner.Row) error {
+		var schema, name, refSchema, refName string
+		if err := row.Columns(&schema, &name, &refSchema, &refName); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		if table, ok := byName[qualifiedTableName(schema, name)]; ok {
+			table.References = append(table.References, qualifiedTableName(refSchema, refName))
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// spannerTableExists reports whether table exists by reading no rows of column. Only a NotFound error means it
+// does not exist, any other error is returned.
+func spannerTableExists(ctx context.Context, client *spanner.Client, table, column string) (bool, error) {
+	iter := client.Single().Read(ctx, table, spanner.KeySets(), []string{column})
+	err := iter.Do(func(*spanner.Row) error { return nil })
+	switch {
+	case err == nil:
+		return true, nil
+	case spanner.ErrCode(err) == codes.NotFound:
+		return false, nil
+	default:
+		return false, errors.Wrapf(err, "spanner.RowIterator.Do(): %s", table)
+	}
+}
+
+// spannerQueryRows calls fn for each row returned by query
+func spannerQueryRows(ctx context.Context, client *spanner.Client, query string, fn func(row *spanner.Row) error) error {
+	iter := client.Single().Query(ctx, spanner.NewStatement(query))
+	defer iter.Stop()
+
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "spanner.RowIterator.Next()")
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// sortTableDrops orders tables so every interleaved child comes before its parent, at any depth, and referencing
+// tables come before the tables their foreign keys reference. Tables that are ready at the same time are ordered
+// by schema and name.
+//
+// Foreign keys do not have to be followed, as they are dropped before any table, and deletes and inserts applied
+// in a single commit are only checked against them at commit. So when the references have a cycle, the tables are
+// ordered by their interleave parents alone, and only a cycle of interleave parents is an error.
+func sortTableDrops(tables []*spannerTable) ([]*spannerTable, error) {
+	sorted, err := sortTables(tables, true)
+	var cycleErr *TableDependencyCycleError
+	if errors.As(err, &cycleErr) {
+		return sortTables(tables, false)
+	}
+
+	return sorted, err
+}
+
+// sortTables orders tables by their interleave parents, and by their foreign key references when withReferences
+// is set. A table referencing itself is not a cycle.
+func sortTables(tables []*spannerTable, withReferences bool) ([]*spannerTable, error) {
+	byName := make(map[string]*spannerTable, len(tables))
+	for _, t := range tables {
+		byName[t.qualifiedName()] = t
+	}
+
+	// dependents counts the remaining tables that depend on each table
+	dependents := make(map[string]int, len(tables))
+	for _, t := range tables {
+		for _, dep := range t.dependencies(byName, withReferences) {
+			dependents[dep]++
+		}
+	}
+
+	var ready []*spannerTable
+	for _, t := range tables {
+		if dependents[t.qualifiedName()] == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	sorted := make([]*spannerTable, 0, len(tables))
+	for len(ready) > 0 {
+		slices.SortFunc(ready, func(a, b *spannerTable) int {
+			return cmp.Or(cmp.Compare(a.Schema, b.Schema), cmp.Compare(a.Name, b.Name))
+		})
+		t := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, t)
+
+		for _, dep := range t.dependencies(byName, withReferences) {
+			dependents[dep]--
+			if dependents[dep] == 0 {
+				ready = append(ready, byName[dep])
+			}
+		}
+	}
+
+	if len(sorted) < len(tables) {
+		var cycle []string
+		for _, t := range tables {
+			if !slices.Contains(sorted, t) {
+				cycle = append(cycle, t.qualifiedName())
+			}
+		}
+		slices.Sort(cycle)
+
+		return nil, &TableDependencyCycleError{Tables: cycle}
+	}
+
+	return sorted, nil
+}
+
+// dependencies returns the distinct tables in byName that t depends on, other than itself. The foreign key
+// references are included when withReferences is set.
+func (t *spannerTable) dependencies(byName map[string]*spannerTable, withReferences bool) []string {
+	dependsOn := []string{t.Parent}
+	if withReferences {
+		dependsOn = append(dependsOn, t.References...)
+	}
+
+	var deps []string
+	for _, dep := range dependsOn {
+		if _, ok := byName[dep]; ok && dep != t.qualifiedName() && !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps
+}
+
+// viewTableReferences returns the tables and views the view definition reads from, qualified with their named schema
+// when they are not in the default schema. Names of common table expressions are left out.
+func viewTableReferences(definition string) ([]string, error) {
+	query, err := memefish.ParseQuery("", definition)
+	if err != nil {
+		return nil, errors.Wrap(err, "memefish.ParseQuery()")
+	}
+
+	ctes := make(map[string]bool)
+	var refs []string
+	ast.Inspect(query, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CTE:
+			ctes[strings.ToLower(n.Name.Name)] = true
+		case *ast.TableName:
+			refs = append(refs, n.Table.Name)
+		case *ast.PathTableExpr:
+			names := make([]string, 0, len(n.Path.Idents))
+			for _, ident := range n.Path.Idents {
+				names = append(names, ident.Name)
+			}
+			refs = append(refs, strings.Join(names, "."))
+		}
+
+		return true
+	})
+
+	return slices.DeleteFunc(refs, func(ref string) bool { return ctes[strings.ToLower(ref)] }), nil
+}
@@ -0,0 +1,163 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/errors/v5"
+)
+
+func Test_sortTableDrops(t *testing.T) {
+	t.Parallel()
+
+	// interleave hierarchy deeper than any fixed number of joins: Level0 > Level1 > ... > Level9
+	var deep []*spannerTable
+	var deepWant []string
+	for i := range 10 {
+		table := &spannerTable{Name: fmt.Sprintf("Level%d", i)}
+		if i > 0 {
+			table.Parent = fmt.Sprintf("Level%d", i-1)
+		}
+		deep = append(deep, table)
+		deepWant = append([]string{table.Name}, deepWant...)
+	}
+
+	tests := []struct {
+		name      string
+		tables    []*spannerTable
+		want      []string
+		wantCycle []string
+	}{
+		{
+			name:   "interleaved children at any depth before their parents",
+			tables: deep,
+			want:   deepWant,
+		},
+		{
+			name: "referencing tables before referenced tables",
+			tables: []*spannerTable{
+				{Name: "Accounts"},
+				{Name: "Invoices", References: []string{"Orders"}},
+				{Name: "Orders", References: []string{"Accounts", "Products"}},
+				{Name: "Products"},
+			},
+			want: []string{"Invoices", "Orders", "Accounts", "Products"},
+		},
+		{
+			name: "self references and unknown tables are ignored",
+			tables: []*spannerTable{
+				{Name: "Categories", References: []string{"Categories", "Missing"}},
+				{Name: "Products", Parent: "Categories", References: []string{"Categories"}},
+			},
+			want: []string{"Products", "Categories"},
+		},
+		{
+			name: "named schemas",
+			tables: []*spannerTable{
+				{Name: "Accounts"},
+				{Schema: "Sales", Name: "Invoices", References: []string{"Accounts", "Sales.Lines"}},
+				{Schema: "Sales", Name: "Lines"},
+			},
+			want: []string{"Sales.Invoices", "Accounts", "Sales.Lines"},
+		},
+		{
+			name: "foreign key cycle falls back to interleave parents",
+			tables: []*spannerTable{
+				{Name: "Accounts", References: []string{"Owners"}},
+				{Name: "Owners", References: []string{"Accounts"}},
+				{Name: "Profiles", Parent: "Owners", References: []string{"Accounts"}},
+			},
+			want: []string{"Accounts", "Profiles", "Owners"},
+		},
+		{
+			name: "interleave cycle",
+			tables: []*spannerTable{
+				{Name: "Accounts", Parent: "Owners"},
+				{Name: "Owners", Parent: "Accounts"},
+				{Name: "Profiles", Parent: "Accounts"},
+				{Name: "Settings", References: []string{"Accounts"}},
+			},
+			wantCycle: []string{"Accounts", "Owners"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sorted, err := sortTableDrops(tt.tables)
+			if tt.wantCycle != nil {
+				var cycleErr *TableDependencyCycleError
+				if !errors.As(err, &cycleErr) {
+					t.Fatalf("sortTableDrops() error = %v, want *TableDependencyCycleError", err)
+				}
+				if !reflect.DeepEqual(cycleErr.Tables, tt.wantCycle) {
+					t.Errorf("TableDependencyCycleError.Tables = %v, want %v", cycleErr.Tables, tt.wantCycle)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("sortTableDrops() error = %v", err)
+			}
+
+			var got []string
+			for _, table := range sorted {
+				got = append(got, table.qualifiedName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortTableDrops() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_viewTableReferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		definition string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "Join of tables",
+			definition: "SELECT o.Id, p.Name FROM Orders o JOIN Products p ON p.Id = o.ProductId",
+			want:       []string{"Orders", "Products"},
+		},
+		{
+			name:       "Column and alias named like a table",
+			definition: "SELECT Categories.Name AS Products FROM Categories WHERE Categories.Name != 'Orders'",
+			want:       []string{"Categories"},
+		},
+		{
+			name:       "Named schema",
+			definition: "SELECT Id FROM Sales.Invoices",
+			want:       []string{"Sales.Invoices"},
+		},
+		{
+			name:       "Common table expression and subquery",
+			definition: "WITH Recent AS (SELECT Id FROM Orders) SELECT Id FROM Recent WHERE Id IN (SELECT OrderId FROM Shipments)",
+			want:       []string{"Orders", "Shipments"},
+		},
+		{
+			name:       "Invalid definition",
+			definition: "SELECT FROM",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := viewTableReferences(tt.definition)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("viewTableReferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("viewTableReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package dbinitiator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/go-playground/errors/v5"
+	spannerDriver "github.com/golang-migrate/migrate/v4/database/spanner"
+)
+
+// spannerTemplateMutationBatch is the number of rows inserted per commit when copying rows into a new database
+const spannerTemplateMutationBatch = 500
+
+// SpannerTemplate creates databases from the schema of a database migrated up once, which is much faster than
+// migrating each database. Use [SpannerContainer.Template] to create one.
+type SpannerTemplate struct {
+	container    *SpannerContainer
+	sourceURLs   []string
+	sourceFS     fs.FS
+	resetVersion bool
+	seedData     bool
+}
+
+// spannerTemplateState holds the schema and rows captured for a template within the process
+type spannerTemplateState struct {
+	mu        sync.Mutex
+	ready     bool
+	ddl       []string
+	mutations []*spanner.Mutation
+}
+
+// Template returns a SpannerTemplate for the migrations from sourceURL, applied in order as with [SpannerDB.MigrateUp].
+func (sc *SpannerContainer) Template(sourceURL ...string) *SpannerTemplate {
+	return &SpannerTemplate{
+		container:  sc,
+		sourceURLs: sourceURL,
+	}
+}
+
+// WithVersionReset clears the migration version before applying each source, as with [SpannerDB.WithVersionReset].
+func (t *SpannerTemplate) WithVersionReset() *SpannerTemplate {
+	t.resetVersion = true
+
+	return t
+}
+
+// WithSourceFS reads migrations from fsys, as with [SpannerDB.WithSourceFS].
+func (t *SpannerTemplate) WithSourceFS(fsys fs.FS) *SpannerTemplate {
+	t.sourceFS = fsys
+
+	return t
+}
+
+// WithSeedData copies the rows of every table left by the migrations into each new database.
+// Only the schema migrations table is copied by default.
+func (t *SpannerTemplate) WithSeedData() *SpannerTemplate {
+	t.seedData = true
+
+	return t
+}
+
+// CreateDatabase creates a database with dbName from the template. Each test should create their own database for testing.
+//
+// The first call for a given content of the migrations applies them to a scratch database, captures its DDL with
+// GetDatabaseDdl along with the rows to copy, and drops it. Each database is then created with the DDL as the extra
+// statements of its CreateDatabaseRequest, and the rows are inserted. Concurrent calls wait for the first one.
+func (t *SpannerTemplate) CreateDatabase(ctx context.Context, dbName string) (*SpannerDB, error) {
+	sc := t.container
+
+	hash, err := t.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := sc.prepareTemplate(ctx, hash, t)
+	if err != nil {
+		return nil, err
+	}
+
+	dbName = sc.validDatabaseName(dbName)
+	db, err := newSpannerDatabase(ctx, sc.admin, sc.projectID, sc.instanceID, dbName, state.ddl, sc.opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create spanner database %s from template", dbName)
+	}
+	db.WithSourceFS(t.sourceFS)
+
+	for batch := range slices.Chunk(state.mutations, spannerTemplateMutationBatch) {
+		if _, err := db.Client.Apply(ctx, batch); err != nil {
+			_ = db.DropDatabase(context.Background())
+			_ = db.Close()
+
+			return nil, errors.Wrapf(err, "spanner.Client.Apply(): database %s", dbName)
+		}
+	}
+
+	return db, nil
+}
+
+// hash returns the hex encoded SHA-256 of the up migrations of every source, along with the settings that change
+// the template
+func (t *SpannerTemplate) hash() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "reset=%t\nseed=%t\n", t.resetVersion, t.seedData)
+
+	for _, sourceURL := range t.sourceURLs {
+		fmt.Fprintf(h, "source=%s\n", sourceURL)
+		if err := hashSource(h, t.sourceFS, sourceURL); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// prepareTemplate returns the captured template for hash, migrating a scratch database to capture it the first time
+func (sc *SpannerContainer) prepareTemplate(ctx context.Context, hash string, t *SpannerTemplate) (*spannerTemplateState, error) {
+	sc.tMu.Lock()
+	if sc.templates == nil {
+		sc.templates = make(map[string]*spannerTemplateState)
+	}
+	state, ok := sc.templates[hash]
+	if !ok {
+		state = &spannerTemplateState{}
+		sc.templates[hash] = state
+	}
+	sc.tMu.Unlock()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.ready {
+		return state, nil
+	}
+
+	// the scratch name is unique, as processes sharing an attached emulator do not share the cache
+	dbName := "tmpl-" + hash[:12] + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	db, err := newSpannerDatabase(ctx, sc.admin, sc.projectID, sc.instanceID, dbName, nil, sc.opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create spanner database %s", dbName)
+	}
+	defer func() {
+		_ = db.DropDatabase(context.Background())
+		_ = db.Close()
+	}()
+
+	db.WithSourceFS(t.sourceFS)
+	if t.resetVersion {
+		db.WithVersionReset()
+	}
+
+	if err := db.MigrateUp(t.sourceURLs...); err != nil {
+		return nil, errors.Wrapf(err, "failed to migrate template database %s", dbName)
+	}
+
+	resp, err := sc.admin.GetDatabaseDdl(ctx, &databasepb.GetDatabaseDdlRequest{Database: db.dbStr})
+	if err != nil {
+		return nil, errors.Wrapf(err, "database.DatabaseAdminClient.GetDatabaseDdl(): %s", db.dbStr)
+	}
+
+	tables, err := spannerTableDependencies(ctx, db.Client)
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := sortTableDrops(tables)
+	if err != nil {
+		return nil, err
+	}
+	// parents and referenced tables are inserted first, the reverse of the drop order
+	slices.Reverse(sorted)
+	if !t.seedData {
+		sorted = slices.DeleteFunc(sorted, func(table *spannerTable) bool {
+			return table.qualifiedName() != spannerDriver.DefaultMigrationsTable
+		})
+	}
+
+	mutations, err := spannerInsertMutations(ctx, db.Client, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	state.ddl = resp.GetStatements()
+	state.mutations = mutations
+	state.ready = true
+
+	return state, nil
+}
+
+// spannerInsertMutations reads every row of tables and returns the mutations that insert them, in the order of tables.
+// Generated columns are left out, as they cannot be written.
+func spannerInsertMutations(ctx context.Context, client *spanner.Client, tables []*spannerTable) ([]*spanner.Mutation, error) {
+	columnsQuery := `
+		SELECT table_schema, table_name, column_name
+		FROM information_schema.columns
+		WHERE NOT table_schema IN('INFORMATION_SCHEMA', 'SPANNER_SYS')
+			AND is_generated = 'NEVER'
+		ORDER BY table_schema, table_name, ordinal_position`
+
+	columns := make(map[string][]string)
+	if err := spannerQueryRows(ctx, client, columnsQuery, func(row *spanner.Row) error {
+		var schema, table, column string
+		if err := row.Columns(&schema, &table, &column); err != nil {
+			return errors.Wrap(err, "spanner.Row.Columns()")
+		}
+		name := qualifiedTableName(schema, table)
+		columns[name] = append(columns[name], column)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var mutations []*spanner.Mutation
+	for _, table := range tables {
+		cols := columns[table.qualifiedName()]
+		if len(cols) == 0 {
+			continue
+		}
+
+		from := "`" + table.Name + "`"
+		if table.Schema != "" {
+			from = "`" + table.Schema + "`." + from
+		}
+		query := "SELECT `" + strings.Join(cols, "`, `") + "` FROM " + from
+
+		if err := spannerQueryRows(ctx, client, query, func(row *spanner.Row) error {
+			vals := make([]any, row.Size())
+			for i := range vals {
+				var v spanner.GenericColumnValue
+				if err := row.Column(i, &v); err != nil {
+					return errors.Wrap(err, "spanner.Row.Column()")
+				}
+				vals[i] = v
+			}
+			mutations = append(mutations, spanner.Insert(table.qualifiedName(), cols, vals))
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return mutations, nil
+}
@@ -0,0 +1,130 @@
+package dbinitiator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestSpannerTemplate_hash(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/1_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT64) PRIMARY KEY (id);")},
+		"migrations/1_init.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+	changed := fstest.MapFS{
+		"migrations/1_init.up.sql":   {Data: []byte("CREATE TABLE a (id STRING(MAX)) PRIMARY KEY (id);")},
+		"migrations/1_init.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+
+	container := &SpannerContainer{}
+	hash := func(tmpl *SpannerTemplate) string {
+		t.Helper()
+
+		h, err := tmpl.hash()
+		if err != nil {
+			t.Fatalf("SpannerTemplate.hash() error = %v", err)
+		}
+
+		return h
+	}
+
+	want := hash(container.Template("migrations").WithSourceFS(fsys))
+	tests := []struct {
+		name     string
+		tmpl     *SpannerTemplate
+		wantSame bool
+	}{
+		{name: "Same contents", tmpl: container.Template("migrations").WithSourceFS(fsys), wantSame: true},
+		{name: "Up migration changed", tmpl: container.Template("migrations").WithSourceFS(changed)},
+		{name: "Seed data", tmpl: container.Template("migrations").WithSourceFS(fsys).WithSeedData()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := hash(tt.tmpl); (got == want) != tt.wantSame {
+				t.Errorf("SpannerTemplate.hash() = %s, base = %s, wantSame %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestSpannerTemplate_CreateDatabase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("New(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	sources := []string{"file://testdata/spanner/migrations_full", "file://testdata/spanner/datamigrations_full"}
+
+	tests := []struct {
+		name           string
+		seedData       bool
+		wantCategories int64
+	}{
+		{
+			name: "Schema only",
+		},
+		{
+			name:           "With seed data",
+			seedData:       true,
+			wantCategories: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl := container.Template(sources...).WithVersionReset()
+			if tt.seedData {
+				tmpl.WithSeedData()
+			}
+
+			var wg sync.WaitGroup
+			dbs := make([]*SpannerDB, 3)
+			errs := make([]error, len(dbs))
+			for i := range dbs {
+				wg.Go(func() {
+					dbs[i], errs[i] = tmpl.CreateDatabase(ctx, genDBName())
+				})
+			}
+			wg.Wait()
+
+			for i, db := range dbs {
+				if errs[i] != nil {
+					t.Fatalf("SpannerTemplate.CreateDatabase() error = %v", errs[i])
+				}
+				defer db.Close()
+
+				var version int64
+				if err := db.Single().Query(ctx, spanner.NewStatement(`SELECT Version FROM SchemaMigrations WHERE NOT Dirty`)).Do(func(r *spanner.Row) error {
+					return r.Columns(&version)
+				}); err != nil {
+					t.Fatalf("SchemaMigrations query error = %v", err)
+				}
+				if version != 1 {
+					t.Errorf("database %d SchemaMigrations version = %d, want 1", i, version)
+				}
+
+				var categories int64
+				if err := db.Single().Query(ctx, spanner.NewStatement(`SELECT COUNT(*) FROM Categories`)).Do(func(r *spanner.Row) error {
+					return r.Columns(&categories)
+				}); err != nil {
+					t.Fatalf("Categories query error = %v", err)
+				}
+				if categories != tt.wantCategories {
+					t.Errorf("database %d Categories rows = %d, want %d", i, categories, tt.wantCategories)
+				}
+			}
+		})
+	}
+}
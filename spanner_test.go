@@ -4,8 +4,12 @@ package dbinitiator
 import (
 	"context"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/spanner"
+	"github.com/go-playground/errors/v5"
 	"github.com/moby/moby/api/types/network"
+	"github.com/testcontainers/testcontainers-go"
 )
 
 func TestSpanner_FullMigration(t *testing.T) {
@@ -21,6 +25,7 @@ func TestSpanner_FullMigration(t *testing.T) {
 	type args struct {
 		upSourceURL   []string
 		downSourceURL string
+		resetVersion  bool
 	}
 	tests := []struct {
 		name        string
@@ -34,8 +39,16 @@ func TestSpanner_FullMigration(t *testing.T) {
 			args: args{
 				upSourceURL:   []string{"file://testdata/spanner/migrations", "file://testdata/spanner/migrations2"},
 				downSourceURL: "file://testdata/spanner/migrations",
+				resetVersion:  true,
 			},
 		},
+		{
+			name: "Migration up sources without version reset",
+			args: args{
+				upSourceURL: []string{"file://testdata/spanner/migrations", "file://testdata/spanner/migrations2"},
+			},
+			wantUpErr: true,
+		},
 		{
 			name: "Migration up error",
 			args: args{
@@ -87,6 +100,10 @@ func TestSpanner_FullMigration(t *testing.T) {
 				}
 			}()
 
+			if tt.args.resetVersion {
+				db.WithVersionReset()
+			}
+
 			if err := db.MigrateUp(tt.args.upSourceURL...); (err != nil) != tt.wantUpErr {
 				t.Fatalf("DB.MigrateUp() error = %v, wantUpErr %v", err, tt.wantUpErr)
 			}
@@ -101,6 +118,103 @@ func TestSpanner_FullMigration(t *testing.T) {
 	}
 }
 
+func TestSpannerDB_MigrateToAndSteps(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("New(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	const sourceURL = "file://testdata/spanner/migrations_versioned"
+
+	db, err := container.CreateDatabase(ctx, genDBName())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	if err := db.MigrateSteps(sourceURL, 2); err != nil {
+		t.Fatalf("DB.MigrateSteps() error = %v", err)
+	}
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = 'Accounts' AND column_name = 'Email')`); err != nil || !ok {
+		t.Errorf("Email column should exist after 2 steps, got %v, err=%v", ok, err)
+	}
+
+	if err := db.MigrateTo(sourceURL, 1); err != nil {
+		t.Fatalf("DB.MigrateTo() error = %v", err)
+	}
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT NOT EXISTS(SELECT 1 FROM information_schema.columns WHERE table_name = 'Accounts' AND column_name = 'Email')`); err != nil || !ok {
+		t.Errorf("Email column should not exist at version 1, got %v, err=%v", ok, err)
+	}
+
+	if err := db.MigrateSteps(sourceURL, -1); err != nil {
+		t.Fatalf("DB.MigrateSteps() error = %v", err)
+	}
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT NOT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_name = 'Accounts')`); err != nil || !ok {
+		t.Errorf("Accounts table should not exist after stepping down, got %v, err=%v", ok, err)
+	}
+}
+
+func TestSpannerDB_DirtyRecovery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("New(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	const (
+		errorSourceURL = "file://testdata/spanner/migration_error"
+		sourceURL      = "file://testdata/spanner/migrations"
+	)
+
+	db, err := container.CreateDatabase(ctx, genDBName())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	var dirtyErr *DirtyError
+	if err := db.MigrateUp(errorSourceURL); !errors.As(err, &dirtyErr) || dirtyErr.Version != 1 {
+		t.Fatalf("DB.MigrateUp() error = %v, want *DirtyError for version 1", err)
+	}
+
+	if err := db.MigrateUp(sourceURL); !errors.As(err, &dirtyErr) || dirtyErr.Version != 1 {
+		t.Fatalf("DB.MigrateUp() on dirty database error = %v, want *DirtyError for version 1", err)
+	}
+
+	if err := db.RetryDirty(errorSourceURL); !errors.As(err, &dirtyErr) {
+		t.Fatalf("DB.RetryDirty() error = %v, want *DirtyError", err)
+	}
+
+	if err := db.ForceVersion(errorSourceURL, -1); err != nil {
+		t.Fatalf("DB.ForceVersion() error = %v", err)
+	}
+
+	if err := db.MigrateUp(sourceURL); err != nil {
+		t.Fatalf("DB.MigrateUp() after ForceVersion() error = %v", err)
+	}
+}
+
 func TestNewSpannerContainer(t *testing.T) {
 	t.Parallel()
 
@@ -108,20 +222,42 @@ func TestNewSpannerContainer(t *testing.T) {
 	cancel()
 
 	type args struct {
-		ctx context.Context
+		ctx     context.Context
+		options []SpannerContainerOption
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantHost string
-		wantErr  bool
+		name           string
+		args           args
+		wantHost       string
+		wantProjectID  string
+		wantInstanceID string
+		wantErr        bool
 	}{
 		{
 			name: "Container with default host and port",
 			args: args{
 				ctx: context.Background(),
 			},
-			wantHost: "localhost",
+			wantHost:       "localhost",
+			wantProjectID:  "unit-testing",
+			wantInstanceID: "test-instance",
+		},
+		{
+			name: "Container with options",
+			args: args{
+				ctx: context.Background(),
+				options: []SpannerContainerOption{
+					WithSpannerProjectID("custom-project"),
+					WithSpannerInstanceID("custom-instance"),
+					WithSpannerInstanceConfig("emulator-config"),
+					WithSpannerImage("gcr.io/cloud-spanner-emulator/emulator:latest"),
+					WithSpannerStartupTimeout(2 * time.Minute),
+					WithSpannerContainerCustomizers(testcontainers.WithLabels(map[string]string{"db-initiator-test": "true"})),
+				},
+			},
+			wantHost:       "localhost",
+			wantProjectID:  "custom-project",
+			wantInstanceID: "custom-instance",
 		},
 		{
 			name: "Container error from canceled context",
@@ -134,13 +270,17 @@ func TestNewSpannerContainer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			container, err := NewSpannerContainer(tt.args.ctx, "latest")
+			container, err := NewSpannerContainer(tt.args.ctx, "latest", tt.args.options...)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if tt.wantErr {
 				return
 			}
+			t.Cleanup(func() { _ = container.Terminate(context.Background()) })
+			if container.ProjectID() != tt.wantProjectID || container.InstanceID() != tt.wantInstanceID {
+				t.Errorf("container = %s/%s, want %s/%s", container.ProjectID(), container.InstanceID(), tt.wantProjectID, tt.wantInstanceID)
+			}
 			ports, err := container.Ports(tt.args.ctx)
 			if err != nil {
 				t.Fatalf("container.Ports() error = %v, wantErr %v", err, false)
@@ -224,3 +364,133 @@ func TestContainer_validDatabaseName(t *testing.T) {
 		})
 	}
 }
+
+func TestSpannerDB_Truncate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("New(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	db, err := container.CreateDatabase(ctx, genDBName())
+	if err != nil {
+		t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+	}
+	defer func() {
+		if err := db.DropDatabase(context.Background()); err != nil {
+			t.Errorf("DB.DropDatabase() err=%s", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Errorf("DB.Close() err=%s", err)
+		}
+	}()
+
+	if err := db.WithVersionReset().MigrateUp("file://testdata/spanner/migrations_full", "file://testdata/spanner/migrations_deep_interleave", "file://testdata/spanner/migrations_fk_cycle"); err != nil {
+		t.Fatalf("DB.MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("Products", []string{"Id", "Name", "Price", "CreatedAt"}, []any{"p1", "widget", 9.99, spanner.CommitTimestamp}),
+		spanner.Insert("Orders", []string{"Id", "ProductId", "Quantity", "TotalPrice", "OrderDate"}, []any{"o1", "p1", 1, 9.99, spanner.CommitTimestamp}),
+		spanner.Insert("Level0", []string{"Level0Id"}, []any{"a"}),
+		spanner.Insert("Level1", []string{"Level0Id", "Level1Id"}, []any{"a", "b"}),
+		spanner.Insert("Accounts", []string{"Id", "PrimaryOwnerId"}, []any{"a1", "w1"}),
+		spanner.Insert("Owners", []string{"Id", "AccountId"}, []any{"w1", "a1"}),
+	}); err != nil {
+		t.Fatalf("spanner.Client.Apply() error = %v", err)
+	}
+
+	if err := db.Truncate(ctx); err != nil {
+		t.Fatalf("DB.Truncate() error = %v", err)
+	}
+
+	for _, table := range []string{"Products", "Orders", "Level0", "Level1", "Accounts", "Owners"} {
+		if ok, err := assertionQuery(ctx, db.Client, `SELECT NOT EXISTS(SELECT 1 FROM `+table+`)`); err != nil || !ok {
+			t.Errorf("%s should be empty after truncate, got %v, err=%v", table, ok, err)
+		}
+	}
+	if ok, err := assertionQuery(ctx, db.Client, `SELECT EXISTS(SELECT 1 FROM SchemaMigrations)`); err != nil || !ok {
+		t.Errorf("SchemaMigrations should keep its version after truncate, got %v, err=%v", ok, err)
+	}
+}
+
+func TestConnectSpannerEmulator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	container, err := NewSpannerContainer(ctx, "latest")
+	if err != nil {
+		t.Fatalf("New(): %s", err)
+	}
+	t.Cleanup(func() { _ = container.Terminate(ctx) })
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("container.Host() error = %v", err)
+	}
+	port, err := container.MappedPort(ctx, defaultSpannerPort)
+	if err != nil {
+		t.Fatalf("container.MappedPort() error = %v", err)
+	}
+	hostPort := host + ":" + port.Port()
+
+	tests := []struct {
+		name    string
+		options []SpannerContainerOption
+	}{
+		{
+			name: "Existing instance",
+		},
+		{
+			name:    "New instance",
+			options: []SpannerContainerOption{WithSpannerInstanceID("attached-instance")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			attached, err := ConnectSpannerEmulator(ctx, hostPort, tt.options...)
+			if err != nil {
+				t.Fatalf("ConnectSpannerEmulator() error = %v", err)
+			}
+			t.Cleanup(func() { _ = attached.Close() })
+
+			db, err := attached.CreateDatabase(ctx, genDBName())
+			if err != nil {
+				t.Fatalf("SpannerContainer.CreateDatabase() error = %v", err)
+			}
+			defer db.Close()
+
+			if err := db.MigrateUp("file://testdata/spanner/migrations"); err != nil {
+				t.Fatalf("db.MigrateUp() error = %v", err)
+			}
+			if err := attached.Terminate(ctx); err != nil {
+				t.Errorf("SpannerContainer.Terminate() error = %v, want nil for an attached emulator", err)
+			}
+		})
+	}
+}
+
+func TestConnectSpannerEmulator_NoAddress(t *testing.T) {
+	t.Setenv("SPANNER_EMULATOR_HOST", "")
+
+	if _, err := ConnectSpannerEmulator(context.Background(), ""); err == nil {
+		t.Errorf("ConnectSpannerEmulator() error = nil, want error without an emulator address")
+	}
+}
+
+func TestSpannerContainer_TerminateNil(t *testing.T) {
+	t.Parallel()
+
+	var container *SpannerContainer
+	if err := container.Terminate(context.Background()); err != nil {
+		t.Errorf("SpannerContainer.Terminate() error = %v, want nil", err)
+	}
+	if err := (&SpannerContainer{}).Terminate(context.Background()); err != nil {
+		t.Errorf("SpannerContainer.Terminate() error = %v, want nil", err)
+	}
+}
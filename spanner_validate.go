@@ -0,0 +1,199 @@
+package dbinitiator
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudspannerecosystem/memefish"
+	"github.com/cloudspannerecosystem/memefish/ast"
+	"github.com/cloudspannerecosystem/memefish/token"
+	"github.com/go-playground/errors/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// migrationTrack identifies whether a migrations source holds schema or data migrations
+type migrationTrack int
+
+const (
+	schemaTrack migrationTrack = iota
+	dataTrack
+)
+
+// MigrationIssue is a problem found in a migration file
+type MigrationIssue struct {
+	// File is the path of the migration file
+	File string
+
+	// Line and Column are 1-origin
+	Line, Column int
+
+	Message string
+}
+
+// String returns the issue in the form <file>:<line>:<column>: <message>
+func (i MigrationIssue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.File, i.Line, i.Column, i.Message)
+}
+
+// MigrationValidationError is returned when migration files fail validation
+type MigrationValidationError struct {
+	Issues []MigrationIssue
+}
+
+func (e *MigrationValidationError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d migration issue(s) found:", len(e.Issues))
+	for _, issue := range e.Issues {
+		fmt.Fprintf(&b, "\n  %s", issue)
+	}
+
+	return b.String()
+}
+
+// ValidateSpannerSchemaMigrations parses every migration file in the file:// sourceURL with the Spanner GoogleSQL parser.
+// A *MigrationValidationError is returned listing syntax errors and any DML statements, which belong in data migrations.
+func ValidateSpannerSchemaMigrations(sourceURL string) error {
+	if err := validateSpannerMigrations(sourceURL, schemaTrack); err != nil {
+		return errors.Wrap(err, "validateSpannerMigrations()")
+	}
+
+	return nil
+}
+
+// ValidateSpannerDataMigrations parses every migration file in the file:// sourceURL with the Spanner GoogleSQL parser.
+// A *MigrationValidationError is returned listing syntax errors and any DDL statements, which belong in schema migrations.
+func ValidateSpannerDataMigrations(sourceURL string) error {
+	if err := validateSpannerMigrations(sourceURL, dataTrack); err != nil {
+		return errors.Wrap(err, "validateSpannerMigrations()")
+	}
+
+	return nil
+}
+
+func validateSpannerMigrations(sourceURL string, track migrationTrack) error {
+	dir, err := migrationsDir(sourceURL)
+	if err != nil {
+		return err
+	}
+
+	issues, err := spannerMigrationIssues(os.DirFS(dir), dir, track)
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return &MigrationValidationError{Issues: issues}
+	}
+
+	return nil
+}
+
+// migrationsDir returns the directory of a file:// sourceURL, the same way the file source driver resolves it
+func migrationsDir(sourceURL string) (string, error) {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "url.Parse(): %s", sourceURL)
+	}
+	if u.Scheme != "file" {
+		return "", errors.Newf("unsupported source %q: only file:// sources can be validated", sourceURL)
+	}
+
+	dir := u.Opaque
+	if dir == "" {
+		dir = u.Host + u.Path
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	return dir, nil
+}
+
+// spannerMigrationIssues parses the migration files at the top level of fsys. dir is only used to report file paths.
+func spannerMigrationIssues(fsys fs.FS, dir string, track migrationTrack) ([]MigrationIssue, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "fs.ReadDir(): %s", dir)
+	}
+
+	var issues []MigrationIssue
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := source.Parse(entry.Name()); err != nil || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		migr, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "fs.ReadFile(): %s", entry.Name())
+		}
+
+		fileIssues, err := spannerStatementIssues(path.Join(dir, entry.Name()), string(migr), track)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	return issues, nil
+}
+
+// spannerStatementIssues parses a single migration file. Statement kinds are only checked once the file parses cleanly.
+func spannerStatementIssues(filePath, migr string, track migrationTrack) ([]MigrationIssue, error) {
+	stmts, err := memefish.ParseStatements(filePath, migr)
+	if err != nil {
+		var parseErrs memefish.MultiError
+		if !errors.As(err, &parseErrs) {
+			return nil, errors.Wrapf(err, "memefish.ParseStatements(): %s", filePath)
+		}
+
+		issues := make([]MigrationIssue, 0, len(parseErrs))
+		for _, parseErr := range parseErrs {
+			issues = append(issues, MigrationIssue{
+				File:    filePath,
+				Line:    parseErr.Position.Line + 1,
+				Column:  parseErr.Position.Column + 1,
+				Message: parseErr.Message,
+			})
+		}
+
+		return issues, nil
+	}
+
+	file := &token.File{FilePath: filePath, Buffer: migr}
+	partitioned := track == dataTrack && hasPartitionedDMLDirective([]byte(migr))
+
+	var issues []MigrationIssue
+	for _, stmt := range stmts {
+		var message string
+		switch stmt.(type) {
+		case ast.DDL:
+			if track == dataTrack {
+				message = "DDL statement in a data migration, move it to the schema migrations"
+			}
+		case ast.DML:
+			if track == schemaTrack {
+				message = "DML statement in a schema migration, move it to the data migrations"
+			}
+			if _, ok := stmt.(*ast.Insert); ok && partitioned {
+				message = "INSERT statements cannot be run as Partitioned DML"
+			}
+		default:
+			message = fmt.Sprintf("%s statement is neither DDL nor DML and cannot be run as a migration", strings.TrimPrefix(fmt.Sprintf("%T", stmt), "*ast."))
+		}
+		if message == "" {
+			continue
+		}
+
+		line, column := file.ResolvePos(stmt.Pos())
+		issues = append(issues, MigrationIssue{File: filePath, Line: line + 1, Column: column + 1, Message: message})
+	}
+
+	return issues, nil
+}
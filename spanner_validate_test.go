@@ -0,0 +1,106 @@
+package dbinitiator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/errors/v5"
+)
+
+func TestValidateSpannerMigrations(t *testing.T) {
+	t.Parallel()
+
+	const invalidDir = "testdata/spanner/migrations_invalid"
+
+	tests := []struct {
+		name       string
+		validate   func(sourceURL string) error
+		sourceURL  string
+		wantIssues []MigrationIssue
+		wantErr    bool
+	}{
+		{
+			name:      "valid schema migrations",
+			validate:  ValidateSpannerSchemaMigrations,
+			sourceURL: "file://testdata/spanner/migrations_versioned",
+		},
+		{
+			name:      "valid data migrations",
+			validate:  ValidateSpannerDataMigrations,
+			sourceURL: "file://testdata/spanner/datamigrations_full",
+		},
+		{
+			name:      "schema migrations with syntax error and DML",
+			validate:  ValidateSpannerSchemaMigrations,
+			sourceURL: "file://" + invalidDir,
+			wantIssues: []MigrationIssue{
+				{File: invalidDir + "/000001_create_orders.up.sql", Line: 3, Column: 17, Message: "expected token: ), but: ;"},
+				{File: invalidDir + "/000002_seed_orders.down.sql", Line: 1, Column: 1, Message: "DML statement in a schema migration, move it to the data migrations"},
+				{File: invalidDir + "/000002_seed_orders.up.sql", Line: 2, Column: 1, Message: "DML statement in a schema migration, move it to the data migrations"},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "data migrations with syntax error and DDL",
+			validate:  ValidateSpannerDataMigrations,
+			sourceURL: "file://" + invalidDir,
+			wantIssues: []MigrationIssue{
+				{File: invalidDir + "/000001_create_orders.down.sql", Line: 1, Column: 1, Message: "DDL statement in a data migration, move it to the schema migrations"},
+				{File: invalidDir + "/000001_create_orders.up.sql", Line: 3, Column: 17, Message: "expected token: ), but: ;"},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "partitioned DML data migrations",
+			validate:  ValidateSpannerDataMigrations,
+			sourceURL: "file://testdata/spanner/datamigrations_pdml",
+		},
+		{
+			name:      "partitioned DML data migration with INSERT",
+			validate:  ValidateSpannerDataMigrations,
+			sourceURL: "file://testdata/spanner/datamigrations_pdml_invalid",
+			wantIssues: []MigrationIssue{
+				{
+					File:    "testdata/spanner/datamigrations_pdml_invalid/000001_seed_categories.up.sql",
+					Line:    2,
+					Column:  1,
+					Message: "INSERT statements cannot be run as Partitioned DML",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "unsupported source",
+			validate:  ValidateSpannerSchemaMigrations,
+			sourceURL: "github://cccteam/db-initiator/testdata/spanner/migrations",
+			wantErr:   true,
+		},
+		{
+			name:      "missing directory",
+			validate:  ValidateSpannerSchemaMigrations,
+			sourceURL: "file://testdata/spanner/migration_does_not_exist",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.validate(tt.sourceURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var validationErr *MigrationValidationError
+			if !errors.As(err, &validationErr) {
+				if tt.wantIssues != nil {
+					t.Fatalf("validate() error = %v, want *MigrationValidationError", err)
+				}
+
+				return
+			}
+			if !reflect.DeepEqual(validationErr.Issues, tt.wantIssues) {
+				t.Errorf("MigrationValidationError.Issues = %v, want %v", validationErr.Issues, tt.wantIssues)
+			}
+		})
+	}
+}
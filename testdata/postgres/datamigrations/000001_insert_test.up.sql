@@ -0,0 +1 @@
+INSERT INTO Test (Id) VALUES (1);
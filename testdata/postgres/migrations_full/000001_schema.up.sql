@@ -0,0 +1,77 @@
+-- Create enum and composite types
+CREATE TYPE order_status AS ENUM ('pending', 'shipped', 'delivered');
+CREATE TYPE money_amount AS (
+  amount NUMERIC(12, 2),
+  currency CHAR(3)
+);
+
+-- Create a range type, which comes with constructor functions of the same name
+CREATE TYPE float_range AS RANGE (subtype = float8);
+
+-- Create a domain
+CREATE DOMAIN email_address AS TEXT CHECK (VALUE LIKE '%@%');
+
+-- Create a standalone sequence
+CREATE SEQUENCE invoice_number_seq START 1000;
+
+-- Create a function
+CREATE FUNCTION order_total(quantity INTEGER, price NUMERIC) RETURNS NUMERIC
+  LANGUAGE SQL IMMUTABLE
+  AS 'SELECT quantity * price';
+
+-- Create a domain whose check calls a function in the schema
+CREATE FUNCTION is_positive(amount NUMERIC) RETURNS BOOLEAN
+  LANGUAGE SQL IMMUTABLE
+  AS 'SELECT amount > 0';
+CREATE DOMAIN positive_amount AS NUMERIC CHECK (is_positive(VALUE));
+
+-- Create Products table
+CREATE TABLE products (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  price NUMERIC(12, 2) NOT NULL
+);
+
+-- Create index on products
+CREATE INDEX products_name ON products(name);
+
+-- Create Customers table using the domain
+CREATE TABLE customers (
+  id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  email email_address NOT NULL UNIQUE
+);
+
+-- Create Orders table with foreign keys
+CREATE TABLE orders (
+  id SERIAL PRIMARY KEY,
+  product_id INTEGER NOT NULL,
+  customer_id INTEGER NOT NULL,
+  quantity INTEGER NOT NULL,
+  status order_status NOT NULL DEFAULT 'pending',
+  invoice_number BIGINT NOT NULL DEFAULT nextval('invoice_number_seq'),
+  CONSTRAINT fk_orders_products FOREIGN KEY (product_id) REFERENCES products(id),
+  CONSTRAINT fk_orders_customers FOREIGN KEY (customer_id) REFERENCES customers(id)
+);
+
+CREATE INDEX orders_status ON orders(status);
+
+-- Create views for order summaries
+CREATE VIEW order_summary AS
+SELECT
+  o.id AS order_id,
+  p.name AS product_name,
+  o.quantity,
+  order_total(o.quantity, p.price) AS total
+FROM orders o
+JOIN products p ON o.product_id = p.id;
+
+CREATE VIEW shipped_order_summary AS
+SELECT s.*
+FROM order_summary s
+JOIN orders o ON o.id = s.order_id
+WHERE o.status = 'shipped';
+
+CREATE MATERIALIZED VIEW product_order_counts AS
+SELECT product_id, COUNT(*) AS order_count
+FROM orders
+GROUP BY product_id;
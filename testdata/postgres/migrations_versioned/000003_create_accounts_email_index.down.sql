@@ -0,0 +1 @@
+DROP INDEX accounts_email;
@@ -0,0 +1 @@
+CREATE INDEX accounts_email ON accounts(email);
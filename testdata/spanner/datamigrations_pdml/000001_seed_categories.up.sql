@@ -0,0 +1,2 @@
+INSERT INTO Categories (Id, Name, ParentId) VALUES ('cat-001', 'electronics', NULL);
+INSERT INTO Categories (Id, Name, ParentId) VALUES ('cat-002', 'computers', 'cat-001');
@@ -0,0 +1,3 @@
+-- dbinitiator:partitioned-dml
+-- Backfill that may touch more rows than a single transaction allows
+UPDATE Categories SET Name = UPPER(Name) WHERE Name != UPPER(Name);
@@ -0,0 +1,2 @@
+-- dbinitiator:partitioned-dml
+INSERT INTO Categories (Id, Name) VALUES ('cat-001', 'Electronics');
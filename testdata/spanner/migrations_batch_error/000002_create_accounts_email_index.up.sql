@@ -0,0 +1,2 @@
+-- Forcing error, the Email column does not exist
+CREATE INDEX Accounts_Email ON Accounts(Email);
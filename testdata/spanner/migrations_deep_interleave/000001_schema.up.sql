@@ -0,0 +1,87 @@
+-- Create an interleave hierarchy nine levels deep, and a table with a foreign key to its root
+
+CREATE TABLE Level0 (
+  Level0Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id);
+
+CREATE TABLE Level1 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id),
+  INTERLEAVE IN PARENT Level0 ON DELETE CASCADE;
+
+CREATE TABLE Level2 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id),
+  INTERLEAVE IN PARENT Level1 ON DELETE CASCADE;
+
+CREATE TABLE Level3 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id),
+  INTERLEAVE IN PARENT Level2 ON DELETE CASCADE;
+
+CREATE TABLE Level4 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+  Level4Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id, Level4Id),
+  INTERLEAVE IN PARENT Level3 ON DELETE CASCADE;
+
+CREATE TABLE Level5 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+  Level4Id STRING(36) NOT NULL,
+  Level5Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id, Level4Id, Level5Id),
+  INTERLEAVE IN PARENT Level4 ON DELETE CASCADE;
+
+CREATE TABLE Level6 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+  Level4Id STRING(36) NOT NULL,
+  Level5Id STRING(36) NOT NULL,
+  Level6Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id, Level4Id, Level5Id, Level6Id),
+  INTERLEAVE IN PARENT Level5 ON DELETE CASCADE;
+
+CREATE TABLE Level7 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+  Level4Id STRING(36) NOT NULL,
+  Level5Id STRING(36) NOT NULL,
+  Level6Id STRING(36) NOT NULL,
+  Level7Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id, Level4Id, Level5Id, Level6Id, Level7Id),
+  INTERLEAVE IN PARENT Level6 ON DELETE CASCADE;
+
+CREATE TABLE Level8 (
+  Level0Id STRING(36) NOT NULL,
+  Level1Id STRING(36) NOT NULL,
+  Level2Id STRING(36) NOT NULL,
+  Level3Id STRING(36) NOT NULL,
+  Level4Id STRING(36) NOT NULL,
+  Level5Id STRING(36) NOT NULL,
+  Level6Id STRING(36) NOT NULL,
+  Level7Id STRING(36) NOT NULL,
+  Level8Id STRING(36) NOT NULL,
+) PRIMARY KEY(Level0Id, Level1Id, Level2Id, Level3Id, Level4Id, Level5Id, Level6Id, Level7Id, Level8Id),
+  INTERLEAVE IN PARENT Level7 ON DELETE CASCADE;
+
+CREATE TABLE Audits (
+  Id STRING(36) NOT NULL,
+  Level0Id STRING(36) NOT NULL,
+  CONSTRAINT FK_Audits_Level0 FOREIGN KEY (Level0Id) REFERENCES Level0(Level0Id),
+) PRIMARY KEY(Id);
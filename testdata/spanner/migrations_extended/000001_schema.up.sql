@@ -0,0 +1,40 @@
+-- Create a named schema with its own table and index
+CREATE SCHEMA Sales;
+
+CREATE TABLE Sales.Invoices (
+  Id STRING(36) NOT NULL,
+  Total FLOAT64,
+) PRIMARY KEY(Id);
+
+CREATE INDEX Sales.InvoicesByTotal ON Sales.Invoices(Total);
+
+-- Create a sequence used as a column default
+CREATE SEQUENCE OrderIds OPTIONS (sequence_kind = 'bit_reversed_positive');
+
+CREATE TABLE Orders (
+  Id INT64 NOT NULL DEFAULT (GET_NEXT_SEQUENCE_VALUE(SEQUENCE OrderIds)),
+  Item STRING(MAX),
+) PRIMARY KEY(Id);
+
+-- Create a change stream watching Orders
+CREATE CHANGE STREAM OrdersStream FOR Orders;
+
+-- Create fine-grained access roles with grants on the objects above
+CREATE ROLE Analyst;
+CREATE ROLE Auditor;
+
+GRANT SELECT ON TABLE Orders TO ROLE Analyst;
+GRANT SELECT(Item) ON TABLE Orders TO ROLE Auditor;
+GRANT SELECT ON CHANGE STREAM OrdersStream TO ROLE Analyst;
+GRANT EXECUTE ON TABLE FUNCTION READ_OrdersStream TO ROLE Analyst;
+GRANT ROLE Analyst TO ROLE Auditor;
+
+-- Create a property graph over Orders
+CREATE PROPERTY GRAPH OrdersGraph
+  NODE TABLES (Orders);
+
+-- Create a remote model
+CREATE MODEL Classifier
+INPUT (Text STRING(MAX))
+OUTPUT (Label STRING(MAX))
+REMOTE OPTIONS (endpoint = '//aiplatform.googleapis.com/projects/test-project/locations/us-central1/endpoints/1234');
@@ -0,0 +1,14 @@
+-- Create two tables whose foreign keys reference each other
+
+CREATE TABLE Accounts (
+  Id STRING(36) NOT NULL,
+  PrimaryOwnerId STRING(36),
+) PRIMARY KEY (Id);
+
+CREATE TABLE Owners (
+  Id STRING(36) NOT NULL,
+  AccountId STRING(36),
+  CONSTRAINT FK_Owners_Accounts FOREIGN KEY (AccountId) REFERENCES Accounts(Id),
+) PRIMARY KEY (Id);
+
+ALTER TABLE Accounts ADD CONSTRAINT FK_Accounts_Owners FOREIGN KEY (PrimaryOwnerId) REFERENCES Owners(Id);
@@ -0,0 +1,3 @@
+CREATE TABLE Orders (
+  Id STRING(36) NOT NULL,
+) PRIMARY KEY(Id;
@@ -0,0 +1 @@
+DELETE FROM Orders WHERE Id = 'a';
@@ -0,0 +1,2 @@
+-- seed data belongs in the data migrations
+INSERT INTO Orders (Id) VALUES ('a');
@@ -0,0 +1,4 @@
+CREATE TABLE Accounts (
+  Id STRING(36) NOT NULL,
+  Name STRING(MAX),
+) PRIMARY KEY(Id);
@@ -0,0 +1 @@
+ALTER TABLE Accounts DROP COLUMN Email;
@@ -0,0 +1 @@
+ALTER TABLE Accounts ADD COLUMN Email STRING(MAX);
@@ -0,0 +1 @@
+DROP INDEX Accounts_Email;
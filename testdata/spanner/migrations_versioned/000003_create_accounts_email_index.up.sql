@@ -0,0 +1 @@
+CREATE INDEX Accounts_Email ON Accounts(Email);